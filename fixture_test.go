@@ -1,8 +1,13 @@
 package gorequest
 
 import (
+	"net/http"
 	"os"
+	"path/filepath"
 	"testing"
+
+	r "github.com/mscheker/gorequest/request"
+	"github.com/stretchr/testify/assert"
 )
 
 type testJsonStruct struct {
@@ -22,8 +27,58 @@ const (
 	POSTMAN_ECHO_DELETE_ENDPOINT = "https://postman-echo.com/delete"
 
 	TEST_TOKEN = "Z29sYW5ndGVzdA=="
+
+	// postman:password
+	POSTMAN_ECHO_BASIC_AUTH = "Basic cG9zdG1hbjpwYXNzd29yZA=="
 )
 
+// The interactions with postman-echo.com are replayed from testdata, so that the tests run offline. Set
+// GOREQUEST_RECORD=1 to record them again; the credentials are redacted by the cassette, and the responses are saved
+// as received.
+func newPostmanEchoCassette(t *testing.T, name string) r.Cassette {
+	cassette, err := NewCassette(filepath.Join("testdata", name+".json"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if os.Getenv("GOREQUEST_RECORD") != "" {
+		cassette.WithMode(CassetteRecord)
+	} else {
+		cassette.WithMode(CassetteReplay)
+	}
+
+	return cassette
+}
+
+// The cassette redacts the credentials before it matches the requests, so they are checked on the way in.
+type authorizationCheck struct {
+	t        *testing.T
+	expected string
+	next     http.RoundTripper
+}
+
+func expectAuthorization(t *testing.T, expected string, next http.RoundTripper) http.RoundTripper {
+	return &authorizationCheck{t: t, expected: expected, next: next}
+}
+
+func (c *authorizationCheck) RoundTrip(request *http.Request) (*http.Response, error) {
+	assert.Equal(c.t, c.expected, request.Header.Get("Authorization"), "Should have sent the credentials")
+
+	return c.next.RoundTrip(request)
+}
+
+// Keeps the recording of a test that failed (e.g. offline) from replacing the interactions in testdata.
+func saveCassette(t *testing.T, cassette r.Cassette) {
+	if t.Failed() {
+		return
+	}
+
+	if err := cassette.Save(); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestMain(runner *testing.M) {
 	result := -1
 
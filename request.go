@@ -5,6 +5,8 @@ package gorequest
  */
 
 import (
+	"net/url"
+
 	r "github.com/mscheker/gorequest/request"
 )
 
@@ -15,47 +17,223 @@ import (
  */
 var NewRequestBuilder r.RequestBuilderConstructor = r.NewRequestBuilder
 
+/**
+ * Constructor for the built-in retry policy (exponential backoff with jitter),
+ * to be passed to RequestBuilder.WithRetry.
+ */
+var NewBackoffRetryPolicy = r.NewBackoffRetryPolicy
+
+/**
+ * Parses a curl command into a RequestBuilder; see Request.AsCurl for the
+ * reverse.
+ */
+var FromCurl = r.FromCurl
+
+/**
+ * Constructor for the builder of multipart/form-data bodies, to be passed to
+ * RequestBuilder.WithMultipartBody.
+ */
+var NewMultipartBuilder = r.NewMultipartBuilder
+
+/**
+ * Constructors for a long-lived Client, and for the tuned *http.Transport it
+ * uses by default. Builders created from the same Client share its connection
+ * pool.
+ */
+var NewClient = r.NewClient
+var NewTransport = r.NewTransport
+
+/**
+ * Constructor for a Session: a Client with a cookie jar, whose cookies can be
+ * saved to and loaded from a file in one of the formats below.
+ */
+var NewSession = r.NewSession
+
+const (
+	CookieFormatNetscape = r.CookieFormatNetscape
+	CookieFormatJson     = r.CookieFormatJson
+)
+
+/**
+ * Constructors for the built-in cache stores, to be passed to
+ * RequestBuilder.WithCache or Client.WithCache, and the values of
+ * Response.CacheStatus.
+ */
+var NewMemoryCacheStore = r.NewMemoryCacheStore
+var NewDiskCacheStore = r.NewDiskCacheStore
+
+const (
+	CacheNone        = r.CacheNone
+	CacheMiss        = r.CacheMiss
+	CacheHit         = r.CacheHit
+	CacheRevalidated = r.CacheRevalidated
+	CacheStale       = r.CacheStale
+)
+
+/**
+ * Constructor for the policy of RequestBuilder.WithLogger and
+ * Client.WithLogger: what is logged, and what is redacted.
+ */
+var NewLogPolicy = r.NewLogPolicy
+
+/**
+ * Constructor for a recorder of HTTP Archive (HAR 1.2) files, to be passed
+ * to RequestBuilder.WithHarRecorder or Client.WithHarRecorder, and the loader
+ * that turns the entries of a HAR file back into RequestBuilders.
+ */
+var NewHarRecorder = r.NewHarRecorder
+var LoadHar = r.LoadHar
+
+/**
+ * Constructor for a record/replay transport, to be passed to
+ * RequestBuilder.WithTransport or Client.WithTransport, the modes of
+ * Cassette.WithMode, and the built-in matchers of Cassette.WithMatchers.
+ */
+var NewCassette = r.NewCassette
+var MatchMethod = r.MatchMethod
+var MatchUrl = r.MatchUrl
+var MatchBody = r.MatchBody
+var MatchHeaders = r.MatchHeaders
+
+const (
+	CassetteRecordMissing = r.CassetteRecordMissing
+	CassetteRecord        = r.CassetteRecord
+	CassetteReplay        = r.CassetteReplay
+	CassettePassthrough   = r.CassettePassthrough
+)
+
+/**
+ * Constructors for the built-in authorization methods, to be passed to
+ * RequestBuilder.WithAuth (alone, or along with other methods).
+ */
+var NewBasicAuth = r.NewBasicAuth
+var NewBearerAuth = r.NewBearerAuth
+var NewApiKeyAuth = r.NewApiKeyAuth
+
+/**
+ * Placements for NewApiKeyAuth.
+ */
+const (
+	ApiKeyInHeader = r.ApiKeyInHeader
+	ApiKeyInQuery  = r.ApiKeyInQuery
+	ApiKeyInCookie = r.ApiKeyInCookie
+)
+
+/**
+ * Constructor for HTTP Digest authentication, to be passed to
+ * RequestBuilder.WithAuth. Requests sharing the value share the server's
+ * challenge, and are authorized without a 401 round trip.
+ */
+var NewDigestAuth = r.NewDigestAuth
+
+/**
+ * Constructor for AWS Signature Version 4 request signing (S3, MinIO and
+ * the other AWS services), to be passed to RequestBuilder.WithAuth.
+ */
+var NewAwsSigV4 = r.NewAwsSigV4
+
+/**
+ * Payload modes for AwsSigV4.WithPayloadMode.
+ */
+const (
+	AwsPayloadSigned    = r.AwsPayloadSigned
+	AwsPayloadUnsigned  = r.AwsPayloadUnsigned
+	AwsPayloadStreaming = r.AwsPayloadStreaming
+)
+
+/**
+ * Constructors for HTTP Message Signatures (RFC 9421): signers, to be passed
+ * to RequestBuilder.WithAuth, and a verifier for the server side.
+ */
+var NewHmacSignature = r.NewHmacSignature
+var NewRsaPssSignature = r.NewRsaPssSignature
+var NewEd25519Signature = r.NewEd25519Signature
+var NewMessageVerifier = r.NewMessageVerifier
+
+/**
+ * Constructors for OAuth 2.0 authorization, to be passed to
+ * RequestBuilder.WithAuth. Tokens are fetched on first use, cached, and
+ * refreshed before they expire.
+ */
+var NewOAuth2ClientCredentials = r.NewOAuth2ClientCredentials
+var NewOAuth2Password = r.NewOAuth2Password
+var NewOAuth2RefreshToken = r.NewOAuth2RefreshToken
+
+/**
+ * Client used by the convenience methods below. It can be reconfigured (e.g.
+ * DefaultClient.WithTransport(...)) at any time; the change applies to calls
+ * made afterwards. Replacing the variable itself is not synchronized, and
+ * should only be done before any request is made.
+ */
+var DefaultClient r.Client = NewClient()
+
 // ***********************************************
 // ************* Convenience Methods *************
 // ***********************************************
-var builder r.RequestBuilder
 
-func Get(url string) r.Response {
-	return getInstance().WithMethod("GET").WithUrl(url).Build().Do()
+// REMARKS: The convenience methods are safe for concurrent use; each call builds its request from scratch and
+// shares nothing with other calls but DefaultClient (and its connection pool).
+
+func Get(url string) (r.Response, error) {
+	return do(newBuilder().WithMethod("GET").WithUrl(url))
+}
+
+func PostText(url, data string) (r.Response, error) {
+	return do(newBuilder().WithMethod("POST").WithUrl(url).WithTextBody(data))
 }
 
-func PostText(url, data string) r.Response {
-	return getInstance().WithMethod("POST").WithUrl(url).WithTextBody(data).Build().Do()
+func PostJson(url string, data interface{}) (r.Response, error) {
+	return do(newBuilder().WithMethod("POST").WithUrl(url).WithJsonBody(data))
 }
 
-func PostJson(url string, data interface{}) r.Response {
-	return getInstance().WithMethod("POST").WithUrl(url).WithJsonBody(data).Build().Do()
+func PostForm(url string, data url.Values) (r.Response, error) {
+	return do(newBuilder().WithMethod("POST").WithUrl(url).WithFormBody(data))
 }
 
-func PutText(url, data string) r.Response {
-	return getInstance().WithMethod("PUT").WithUrl(url).WithTextBody(data).Build().Do()
+func PutText(url, data string) (r.Response, error) {
+	return do(newBuilder().WithMethod("PUT").WithUrl(url).WithTextBody(data))
 }
 
-func PutJson(url string, data interface{}) r.Response {
-	return getInstance().WithMethod("PUT").WithUrl(url).WithJsonBody(data).Build().Do()
+func PutJson(url string, data interface{}) (r.Response, error) {
+	return do(newBuilder().WithMethod("PUT").WithUrl(url).WithJsonBody(data))
 }
 
-func Delete(url string) r.Response {
-	return getInstance().WithMethod("DELETE").WithUrl(url).Build().Do()
+func PatchText(url, data string) (r.Response, error) {
+	return do(newBuilder().WithMethod("PATCH").WithUrl(url).WithTextBody(data))
 }
 
-func Head(url string) r.Response {
-	return getInstance().WithMethod("HEAD").WithUrl(url).Build().Do()
+func PatchJson(url string, data interface{}) (r.Response, error) {
+	return do(newBuilder().WithMethod("PATCH").WithUrl(url).WithJsonBody(data))
+}
+
+func Delete(url string) (r.Response, error) {
+	return do(newBuilder().WithMethod("DELETE").WithUrl(url))
+}
+
+func Head(url string) (r.Response, error) {
+	return do(newBuilder().WithMethod("HEAD").WithUrl(url))
+}
+
+func Options(url string) (r.Response, error) {
+	return do(newBuilder().WithMethod("OPTIONS").WithUrl(url))
 }
 
 // ***********************************************
 // ********** Private methods/functions **********
 // ***********************************************
 
-func getInstance() r.RequestBuilder {
-	if builder == nil {
-		builder = NewRequestBuilder()
+// REMARKS: Each call gets its own builder; a shared one would carry a failed option (and its error) over to the next call.
+// REMARKS: The builders share DefaultClient, and therefore its connection pool.
+func newBuilder() r.RequestBuilder {
+	return DefaultClient.NewRequestBuilder()
+}
+
+func do(builder r.RequestBuilder) (r.Response, error) {
+	req, err := builder.Build()
+
+	if err != nil {
+		return nil, err
 	}
 
-	return builder
+	return req.Do()
 }
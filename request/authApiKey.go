@@ -0,0 +1,37 @@
+package request
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// REMARKS: Sends a fixed key in a header (e.g. X-API-Key), a query parameter (e.g. ?api_key=) or a cookie. A key in the
+// query is appended to the URL, so it can end up in logs; prefer a header when the API accepts one.
+type authApiKey struct {
+	name      string
+	value     string
+	placement ApiKeyPlacement
+}
+
+func newAuthApiKey(name, value string, placement ApiKeyPlacement) AuthorizationMethod {
+	return &authApiKey{
+		name:      name,
+		value:     value,
+		placement: placement,
+	}
+}
+
+func (a *authApiKey) Configure(request *http.Request) {
+	switch a.placement {
+	case ApiKeyInQuery:
+		if request.URL.RawQuery != "" {
+			request.URL.RawQuery += "&"
+		}
+
+		request.URL.RawQuery += url.QueryEscape(a.name) + "=" + url.QueryEscape(a.value)
+	case ApiKeyInCookie:
+		request.AddCookie(&http.Cookie{Name: a.name, Value: a.value})
+	default:
+		request.Header.Set(a.name, a.value)
+	}
+}
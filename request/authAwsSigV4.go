@@ -0,0 +1,474 @@
+package request
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// REMARKS: AWS Signature Version 4. Ref: https://docs.aws.amazon.com/IAM/latest/UserGuide/create-signed-request.html
+// Requests are signed when they are sent (see Wrap), so every retry gets a fresh X-Amz-Date. Every header present at
+// that point is signed, including those added by middleware; the transport only adds headers (e.g. User-Agent)
+// that are not part of the signature.
+type authAwsSigV4 struct {
+	accessKeyId     string
+	secretAccessKey string
+	sessionToken    string
+	region          string
+	service         string
+	payloadMode     AwsPayloadMode
+	chunkSize       int
+	now             func() time.Time
+}
+
+const (
+	awsAlgorithm           = "AWS4-HMAC-SHA256"
+	awsUnsignedPayload     = "UNSIGNED-PAYLOAD"
+	awsStreamingPayload    = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+	awsChunkAlgorithm      = "AWS4-HMAC-SHA256-PAYLOAD"
+	awsDateFormat          = "20060102T150405Z"
+	awsChunkSignatureField = ";chunk-signature="
+)
+
+// REMARKS: Hash of the empty payload, used for requests without a body and in the chunk signatures.
+var awsEmptyPayloadHash = hashSha256(nil)
+
+// REMARKS: Headers that are commonly changed by proxies (or by the transport) on the way to the server.
+var awsUnsignedHeaders = map[string]bool{
+	"authorization":   true,
+	"user-agent":      true,
+	"x-amzn-trace-id": true,
+	"expect":          true,
+}
+
+func newAuthAwsSigV4(accessKeyId, secretAccessKey, region, service string) *authAwsSigV4 {
+	return &authAwsSigV4{
+		accessKeyId:     accessKeyId,
+		secretAccessKey: secretAccessKey,
+		region:          region,
+		service:         service,
+		chunkSize:       defaultAwsChunkSize,
+		now:             time.Now,
+	}
+}
+
+// REMARKS: For temporary credentials (e.g. from STS); sent as X-Amz-Security-Token.
+func (a *authAwsSigV4) WithSessionToken(token string) AwsSigV4 {
+	a.sessionToken = token
+
+	return a
+}
+
+func (a *authAwsSigV4) WithPayloadMode(mode AwsPayloadMode) AwsSigV4 {
+	a.payloadMode = mode
+
+	return a
+}
+
+// REMARKS: The request is signed when it is sent (see Wrap).
+func (a *authAwsSigV4) Configure(request *http.Request) {
+}
+
+func (a *authAwsSigV4) Wrap(next RoundTripperFunc) RoundTripperFunc {
+	return func(request *http.Request) (*http.Response, error) {
+		if err := a.Sign(request); err != nil {
+			return nil, err
+		}
+
+		return next(request)
+	}
+}
+
+// REMARKS: Signs the request in place, setting the Authorization and X-Amz-* headers. In the streaming payload mode,
+// the body is also replaced with its aws-chunked encoding.
+func (a *authAwsSigV4) Sign(request *http.Request) error {
+	now := a.now().UTC()
+
+	payloadHash, err := a.payloadHash(request)
+
+	if err != nil {
+		return err
+	}
+
+	extra := make(map[string]string)
+
+	request.Header.Del("Authorization")
+	request.Header.Set("X-Amz-Date", now.Format(awsDateFormat))
+
+	if a.sessionToken != "" {
+		request.Header.Set("X-Amz-Security-Token", a.sessionToken)
+	}
+
+	if a.payloadMode == AwsPayloadStreaming {
+		if request.ContentLength < 0 || (request.ContentLength == 0 && request.Body != nil && request.Body != http.NoBody) {
+			return errors.New("The streaming payload mode requires a body of known length.")
+		}
+
+		encoding := "aws-chunked"
+
+		if existing := request.Header.Get("Content-Encoding"); existing != "" {
+			encoding += "," + existing
+		}
+
+		request.Header.Set("Content-Encoding", encoding)
+		request.Header.Set("X-Amz-Decoded-Content-Length", strconv.FormatInt(request.ContentLength, 10))
+
+		// REMARKS: The length of the encoded body is signed as well; net/http sends it from the ContentLength field.
+		request.ContentLength = a.encodedLength(request.ContentLength)
+		extra["content-length"] = strconv.FormatInt(request.ContentLength, 10)
+	}
+
+	if a.service == "s3" || a.payloadMode != AwsPayloadSigned {
+		request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	signedHeaders, canonicalHeaders := a.canonicalHeaders(request, extra)
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		a.canonicalUri(request.URL),
+		a.canonicalQuery(request.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := a.scope(now)
+	key := a.signingKey(now)
+	signature := hex.EncodeToString(hmacSha256(key, a.stringToSign(now, scope, canonicalRequest)))
+
+	request.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s", awsAlgorithm, a.accessKeyId, scope, signedHeaders, signature))
+
+	if a.payloadMode == AwsPayloadStreaming {
+		a.streamBody(request, now, scope, key, signature)
+	}
+
+	return nil
+}
+
+// REMARKS: Returns a URL that can be used without credentials (e.g. by a browser) until it expires; the maximum
+// accepted by S3 is 7 days. The payload is not signed, and only the headers set on the request are signed.
+// Ref: https://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-query-string-auth.html
+func (a *authAwsSigV4) Presign(request Request, expires time.Duration) (string, error) {
+	req := request.getUnderlyingRequest().Clone(request.getUnderlyingRequest().Context())
+	now := a.now().UTC()
+	scope := a.scope(now)
+
+	req.Header.Del("Authorization")
+
+	signedHeaders, canonicalHeaders := a.canonicalHeaders(req, nil)
+
+	query := req.URL.Query()
+	query.Set("X-Amz-Algorithm", awsAlgorithm)
+	query.Set("X-Amz-Credential", a.accessKeyId+"/"+scope)
+	query.Set("X-Amz-Date", now.Format(awsDateFormat))
+	query.Set("X-Amz-Expires", strconv.FormatInt(int64(expires/time.Second), 10))
+	query.Set("X-Amz-SignedHeaders", signedHeaders)
+
+	if a.sessionToken != "" {
+		query.Set("X-Amz-Security-Token", a.sessionToken)
+	}
+
+	canonicalQuery := a.canonicalQuery(query)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		a.canonicalUri(req.URL),
+		canonicalQuery,
+		canonicalHeaders,
+		signedHeaders,
+		awsUnsignedPayload,
+	}, "\n")
+
+	signature := hex.EncodeToString(hmacSha256(a.signingKey(now), a.stringToSign(now, scope, canonicalRequest)))
+
+	presigned := *req.URL
+	presigned.RawQuery = canonicalQuery + "&X-Amz-Signature=" + signature
+
+	return presigned.String(), nil
+}
+
+// ***********************************************
+// ********** Private methods/functions **********
+// ***********************************************
+
+func (a *authAwsSigV4) payloadHash(request *http.Request) (string, error) {
+	switch a.payloadMode {
+	case AwsPayloadUnsigned:
+		return awsUnsignedPayload, nil
+	case AwsPayloadStreaming:
+		return awsStreamingPayload, nil
+	}
+
+	if request.Body == nil || request.Body == http.NoBody {
+		return awsEmptyPayloadHash, nil
+	}
+
+	if request.GetBody == nil {
+		return "", errors.New("Cannot sign a body that can only be read once; use the unsigned or streaming payload mode.")
+	}
+
+	body, err := request.GetBody()
+
+	if err != nil {
+		return "", err
+	}
+
+	defer body.Close()
+
+	hash := sha256.New()
+
+	if _, err := io.Copy(hash, body); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func (a *authAwsSigV4) scope(now time.Time) string {
+	return strings.Join([]string{now.Format("20060102"), a.region, a.service, "aws4_request"}, "/")
+}
+
+func (a *authAwsSigV4) signingKey(now time.Time) []byte {
+	key := hmacSha256([]byte("AWS4"+a.secretAccessKey), now.Format("20060102"))
+	key = hmacSha256(key, a.region)
+	key = hmacSha256(key, a.service)
+
+	return hmacSha256(key, "aws4_request")
+}
+
+func (a *authAwsSigV4) stringToSign(now time.Time, scope, canonicalRequest string) string {
+	return strings.Join([]string{awsAlgorithm, now.Format(awsDateFormat), scope, hashSha256([]byte(canonicalRequest))}, "\n")
+}
+
+// REMARKS: S3 signs the path as it is sent; the other services normalize it, and encode it twice.
+func (a *authAwsSigV4) canonicalUri(u *url.URL) string {
+	if a.service == "s3" {
+		return awsEscape(u.Path, false)
+	}
+
+	p := u.Path
+
+	if p == "" {
+		return "/"
+	}
+
+	clean := path.Clean(p)
+
+	if strings.HasSuffix(p, "/") && clean != "/" {
+		clean += "/"
+	}
+
+	return awsEscape(awsEscape(clean, false), false)
+}
+
+func (a *authAwsSigV4) canonicalQuery(query url.Values) string {
+	var pairs []string
+
+	for key, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, awsEscape(key, true)+"="+awsEscape(value, true))
+		}
+	}
+
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, "&")
+}
+
+// REMARKS: Returns the signed header names (e.g. "host;x-amz-date") and the canonical headers block.
+func (a *authAwsSigV4) canonicalHeaders(request *http.Request, extra map[string]string) (string, string) {
+	headers := map[string][]string{
+		"host": {awsHost(request)},
+	}
+
+	for name, value := range extra {
+		headers[name] = []string{value}
+	}
+
+	for name, values := range request.Header {
+		name = strings.ToLower(name)
+
+		if awsUnsignedHeaders[name] {
+			continue
+		}
+
+		headers[name] = append(headers[name], values...)
+	}
+
+	names := make([]string, 0, len(headers))
+
+	for name := range headers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var canonical strings.Builder
+
+	for _, name := range names {
+		values := make([]string, len(headers[name]))
+
+		for i, value := range headers[name] {
+			values[i] = strings.Join(strings.Fields(value), " ")
+		}
+
+		canonical.WriteString(name + ":" + strings.Join(values, ",") + "\n")
+	}
+
+	return strings.Join(names, ";"), canonical.String()
+}
+
+// REMARKS: Ref: https://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-streaming.html
+func (a *authAwsSigV4) streamBody(request *http.Request, now time.Time, scope string, key []byte, seed string) {
+	newReader := func(body io.ReadCloser) io.ReadCloser {
+		return &awsChunkedReader{
+			body:      body,
+			chunkSize: a.chunkSize,
+			signature: seed,
+			sign: func(previous string, chunk []byte) string {
+				return hex.EncodeToString(hmacSha256(key, strings.Join([]string{
+					awsChunkAlgorithm, now.Format(awsDateFormat), scope, previous, awsEmptyPayloadHash, hashSha256(chunk),
+				}, "\n")))
+			},
+		}
+	}
+
+	body := request.Body
+
+	if body == nil {
+		body = http.NoBody
+	}
+
+	request.Body = newReader(body)
+
+	if getBody := request.GetBody; getBody != nil {
+		request.GetBody = func() (io.ReadCloser, error) {
+			body, err := getBody()
+
+			if err != nil {
+				return nil, err
+			}
+
+			return newReader(body), nil
+		}
+	}
+}
+
+func (a *authAwsSigV4) encodedLength(length int64) int64 {
+	size := int64(a.chunkSize)
+	encoded := awsChunkLength(0)
+
+	for remaining := length; remaining > 0; remaining -= size {
+		n := size
+
+		if remaining < size {
+			n = remaining
+		}
+
+		encoded += awsChunkLength(n)
+	}
+
+	return encoded
+}
+
+func awsChunkLength(size int64) int64 {
+	return int64(len(strconv.FormatInt(size, 16))+len(awsChunkSignatureField)+64+2) + size + 2
+}
+
+// REMARKS: Encodes the body as "<hex size>;chunk-signature=<signature>\r\n<data>\r\n" chunks, each signature covering
+// the chunk and the previous signature, and ends with a signed empty chunk.
+type awsChunkedReader struct {
+	body      io.ReadCloser
+	chunkSize int
+	signature string
+	sign      func(previous string, chunk []byte) string
+	buffer    bytes.Buffer
+	done      bool
+}
+
+func (r *awsChunkedReader) Read(p []byte) (int, error) {
+	for r.buffer.Len() == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+
+		chunk := make([]byte, r.chunkSize)
+		n, err := io.ReadFull(r.body, chunk)
+
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return 0, err
+		}
+
+		r.signature = r.sign(r.signature, chunk[:n])
+		r.done = n == 0
+
+		fmt.Fprintf(&r.buffer, "%x%s%s\r\n", n, awsChunkSignatureField, r.signature)
+		r.buffer.Write(chunk[:n])
+		r.buffer.WriteString("\r\n")
+	}
+
+	return r.buffer.Read(p)
+}
+
+func (r *awsChunkedReader) Close() error {
+	return r.body.Close()
+}
+
+// REMARKS: The default port of the scheme is left out, as it is in the Host header sent by net/http.
+func awsHost(request *http.Request) string {
+	host := request.Host
+
+	if host == "" {
+		host = request.URL.Host
+	}
+
+	if strings.HasSuffix(host, ":80") && request.URL.Scheme == "http" {
+		return strings.TrimSuffix(host, ":80")
+	}
+
+	if strings.HasSuffix(host, ":443") && request.URL.Scheme == "https" {
+		return strings.TrimSuffix(host, ":443")
+	}
+
+	return host
+}
+
+// REMARKS: Percent-encodes everything but the unreserved characters (RFC 3986), and "/" unless encodeSlash is set.
+func awsEscape(s string, encodeSlash bool) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !encodeSlash) {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
+
+func hashSha256(data []byte) string {
+	hash := sha256.Sum256(data)
+
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,34 @@
+package request
+
+import "net/http"
+
+// REMARKS: Applies several authorization methods to the same request, in order (e.g. an API key along with a message
+// signature covering it). When sending, the handlers among them run in the same order, the first one outermost, so
+// each sees the changes made by the ones before it.
+type authChain struct {
+	methods []AuthorizationMethod
+}
+
+func newAuthChain(methods []AuthorizationMethod) AuthorizationHandler {
+	return &authChain{
+		methods: methods,
+	}
+}
+
+func (a *authChain) Configure(request *http.Request) {
+	for _, method := range a.methods {
+		method.Configure(request)
+	}
+}
+
+func (a *authChain) Wrap(next RoundTripperFunc) RoundTripperFunc {
+	var middleware []Middleware
+
+	for _, method := range a.methods {
+		if handler, ok := method.(AuthorizationHandler); ok {
+			middleware = append(middleware, handler.Wrap)
+		}
+	}
+
+	return chainMiddleware(next, middleware)
+}
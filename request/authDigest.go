@@ -0,0 +1,415 @@
+package request
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// REMARKS: HTTP Digest access authentication (RFC 7616). The first request to a host is sent without credentials;
+// the 401 challenge is answered by sending the request once more. The challenge is then kept per host, so later
+// requests are authorized up front, counting their use of the nonce (nc) until the server asks for a new one
+// (stale=true, or a nextnonce in Authentication-Info).
+type authDigest struct {
+	mutex      sync.Mutex
+	username   string
+	password   string
+	challenges map[string]*digestChallenge
+	cnonce     func() string
+}
+
+type digestChallenge struct {
+	realm      string
+	nonce      string
+	opaque     string
+	algorithm  string
+	qop        []string
+	userhash   bool
+	nonceCount uint32
+}
+
+// REMARKS: Ordered by preference, the strongest first. Ref: https://www.rfc-editor.org/rfc/rfc7616#section-3.5
+var digestAlgorithms = []struct {
+	name string
+	hash func() hash.Hash
+}{
+	{"SHA-512-256", sha512.New512_256},
+	{"SHA-256", sha256.New},
+	{"MD5", md5.New},
+}
+
+func newAuthDigest(username, password string) *authDigest {
+	return &authDigest{
+		username:   username,
+		password:   password,
+		challenges: make(map[string]*digestChallenge),
+		cnonce:     newDigestCnonce,
+	}
+}
+
+// REMARKS: The credentials depend on the server's challenge, and are applied when the request is sent (see Wrap).
+func (a *authDigest) Configure(request *http.Request) {
+}
+
+func (a *authDigest) Wrap(next RoundTripperFunc) RoundTripperFunc {
+	return func(request *http.Request) (*http.Response, error) {
+		if challenge := a.challenge(request.URL.Host); challenge != nil {
+			if err := a.authorize(request, challenge); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := next(request)
+
+		if err != nil {
+			return resp, err
+		}
+
+		if resp.StatusCode != http.StatusUnauthorized {
+			a.nextNonce(request.URL.Host, resp.Header.Get("Authentication-Info"))
+
+			return resp, nil
+		}
+
+		challenge := parseDigestChallenge(resp.Header.Values("WWW-Authenticate"))
+
+		if challenge == nil || !canResend(request) {
+			return resp, nil
+		}
+
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+
+		a.mutex.Lock()
+		a.challenges[request.URL.Host] = challenge
+		a.mutex.Unlock()
+
+		retry, err := resend(request)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err := a.authorize(retry, challenge); err != nil {
+			return nil, err
+		}
+
+		return next(retry)
+	}
+}
+
+// ***********************************************
+// ********** Private methods/functions **********
+// ***********************************************
+
+func (a *authDigest) challenge(host string) *digestChallenge {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return a.challenges[host]
+}
+
+// REMARKS: Ref: https://www.rfc-editor.org/rfc/rfc7616#section-3.5
+func (a *authDigest) nextNonce(host, info string) {
+	if info == "" {
+		return
+	}
+
+	nonce, ok := parseAuthParams(info)["nextnonce"]
+
+	if !ok {
+		return
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if challenge, ok := a.challenges[host]; ok {
+		renewed := *challenge
+		renewed.nonce = nonce
+		renewed.nonceCount = 0
+
+		a.challenges[host] = &renewed
+	}
+}
+
+// REMARKS: Ref: https://www.rfc-editor.org/rfc/rfc7616#section-3.4
+func (a *authDigest) authorize(request *http.Request, challenge *digestChallenge) error {
+	a.mutex.Lock()
+	challenge.nonceCount++
+	nc := fmt.Sprintf("%08x", challenge.nonceCount)
+	a.mutex.Unlock()
+
+	newHash := digestHash(challenge.algorithm)
+	h := func(s string) string {
+		hash := newHash()
+		io.WriteString(hash, s)
+
+		return hex.EncodeToString(hash.Sum(nil))
+	}
+
+	cnonce := a.cnonce()
+	uri := request.URL.RequestURI()
+	qop := ""
+
+	for _, q := range challenge.qop {
+		if q == "auth" || (q == "auth-int" && qop == "") {
+			qop = q
+		}
+	}
+
+	ha1 := h(a.username + ":" + challenge.realm + ":" + a.password)
+
+	if strings.HasSuffix(strings.ToUpper(challenge.algorithm), "-SESS") {
+		ha1 = h(ha1 + ":" + challenge.nonce + ":" + cnonce)
+	}
+
+	ha2 := h(request.Method + ":" + uri)
+
+	// REMARKS: auth-int is only used when the server doesn't offer auth; hashing the body needs a body that can be read again.
+	if qop == "auth-int" {
+		if !canResend(request) {
+			return &ValidationError{Option: "WithDigestAuth", Err: errors.New("The server requires qop=auth-int, which needs a body that can be read again (e.g. not WithBodyReader).")}
+		}
+
+		hash := newHash()
+
+		if request.GetBody != nil {
+			body, err := request.GetBody()
+
+			if err != nil {
+				return err
+			}
+
+			_, err = io.Copy(hash, body)
+			body.Close()
+
+			if err != nil {
+				return err
+			}
+		}
+
+		ha2 = h(request.Method + ":" + uri + ":" + hex.EncodeToString(hash.Sum(nil)))
+	}
+
+	var response string
+
+	if qop == "" {
+		response = h(ha1 + ":" + challenge.nonce + ":" + ha2)
+	} else {
+		response = h(strings.Join([]string{ha1, challenge.nonce, nc, cnonce, qop, ha2}, ":"))
+	}
+
+	username := a.username
+
+	if challenge.userhash {
+		username = h(a.username + ":" + challenge.realm)
+	}
+
+	params := []string{
+		fmt.Sprintf(`username="%s"`, escapeQuotes(username)),
+		fmt.Sprintf(`realm="%s"`, escapeQuotes(challenge.realm)),
+		fmt.Sprintf(`uri="%s"`, escapeQuotes(uri)),
+		fmt.Sprintf(`algorithm=%s`, challenge.algorithm),
+		fmt.Sprintf(`nonce="%s"`, escapeQuotes(challenge.nonce)),
+	}
+
+	if qop != "" {
+		params = append(params, fmt.Sprintf("nc=%s", nc), fmt.Sprintf(`cnonce="%s"`, cnonce), fmt.Sprintf("qop=%s", qop))
+	}
+
+	params = append(params, fmt.Sprintf(`response="%s"`, response))
+
+	if challenge.opaque != "" {
+		params = append(params, fmt.Sprintf(`opaque="%s"`, escapeQuotes(challenge.opaque)))
+	}
+
+	if challenge.userhash {
+		params = append(params, "userhash=true")
+	}
+
+	request.Header.Set("Authorization", "Digest "+strings.Join(params, ", "))
+
+	return nil
+}
+
+// REMARKS: Picks the strongest supported algorithm when the server offers several Digest challenges. Returns nil
+// if there is no Digest challenge, or none with an algorithm that is supported.
+func parseDigestChallenge(headers []string) *digestChallenge {
+	var best *digestChallenge
+	bestRank := len(digestAlgorithms)
+
+	for _, header := range headers {
+		for _, c := range splitChallenges(header) {
+			if !strings.EqualFold(c.scheme, "Digest") {
+				continue
+			}
+
+			algorithm := c.params["algorithm"]
+
+			if algorithm == "" {
+				algorithm = "MD5"
+			}
+
+			rank := digestRank(algorithm)
+
+			if rank >= bestRank {
+				continue
+			}
+
+			challenge := &digestChallenge{
+				realm:     c.params["realm"],
+				nonce:     c.params["nonce"],
+				opaque:    c.params["opaque"],
+				algorithm: algorithm,
+				userhash:  strings.EqualFold(c.params["userhash"], "true"),
+			}
+
+			for _, q := range strings.Split(c.params["qop"], ",") {
+				if q = strings.TrimSpace(q); q == "auth" || q == "auth-int" {
+					challenge.qop = append(challenge.qop, q)
+				}
+			}
+
+			best, bestRank = challenge, rank
+		}
+	}
+
+	return best
+}
+
+func digestRank(algorithm string) int {
+	name := strings.TrimSuffix(strings.ToUpper(algorithm), "-SESS")
+
+	for i, a := range digestAlgorithms {
+		if a.name == name {
+			return i
+		}
+	}
+
+	return len(digestAlgorithms)
+}
+
+func digestHash(algorithm string) func() hash.Hash {
+	return digestAlgorithms[digestRank(algorithm)].hash
+}
+
+func newDigestCnonce() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+type authChallenge struct {
+	scheme string
+	params map[string]string
+}
+
+// REMARKS: A WWW-Authenticate header may hold several challenges, e.g. `Basic realm="a", Digest realm="b", nonce="c"`.
+// A new challenge starts at a token that isn't followed by "=". Ref: https://www.rfc-editor.org/rfc/rfc9110#section-11.6.1
+func splitChallenges(header string) []authChallenge {
+	var challenges []authChallenge
+
+	for _, item := range splitAuthItems(header) {
+		if i := strings.IndexAny(item, " \t"); i > 0 && !strings.Contains(item[:i], "=") {
+			challenges = append(challenges, authChallenge{scheme: item[:i], params: make(map[string]string)})
+			item = strings.TrimSpace(item[i:])
+		} else if !strings.Contains(item, "=") {
+			challenges = append(challenges, authChallenge{scheme: item, params: make(map[string]string)})
+			continue
+		}
+
+		if len(challenges) == 0 {
+			continue
+		}
+
+		if key, value, ok := parseAuthParam(item); ok {
+			challenges[len(challenges)-1].params[key] = value
+		}
+	}
+
+	return challenges
+}
+
+func parseAuthParams(header string) map[string]string {
+	params := make(map[string]string)
+
+	for _, item := range splitAuthItems(header) {
+		if key, value, ok := parseAuthParam(item); ok {
+			params[key] = value
+		}
+	}
+
+	return params
+}
+
+// REMARKS: Splits on the commas that are not inside a quoted string.
+func splitAuthItems(header string) []string {
+	var items []string
+	var quoted, escaped bool
+	start := 0
+
+	for i, c := range header {
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\' && quoted:
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case c == ',' && !quoted:
+			if item := strings.TrimSpace(header[start:i]); item != "" {
+				items = append(items, item)
+			}
+
+			start = i + 1
+		}
+	}
+
+	if item := strings.TrimSpace(header[start:]); item != "" {
+		items = append(items, item)
+	}
+
+	return items
+}
+
+func parseAuthParam(item string) (key, value string, ok bool) {
+	i := strings.Index(item, "=")
+
+	if i <= 0 {
+		return "", "", false
+	}
+
+	key = strings.ToLower(strings.TrimSpace(item[:i]))
+	value = strings.TrimSpace(item[i+1:])
+
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		var b strings.Builder
+		escaped := false
+
+		for _, c := range value[1 : len(value)-1] {
+			if c == '\\' && !escaped {
+				escaped = true
+				continue
+			}
+
+			escaped = false
+			b.WriteRune(c)
+		}
+
+		value = b.String()
+	}
+
+	return key, value, true
+}
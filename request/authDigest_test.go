@@ -0,0 +1,276 @@
+package request
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Ref: https://www.rfc-editor.org/rfc/rfc7616#section-3.9.1
+func TestDigestAuthRFC7616Example(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		response  string
+	}{
+		{"MD5", "8ca523f5e9506fed4657c9700eebdbec"},
+		{"SHA-256", "753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1"},
+	}
+
+	for _, test := range tests {
+		header := fmt.Sprintf(`Digest realm="http-auth@example.org", qop="auth, auth-int", algorithm=%s, `+
+			`nonce="7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v", opaque="FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"`, test.algorithm)
+
+		challenge := parseDigestChallenge([]string{header})
+
+		assert.NotNil(t, challenge, "Should not be nil")
+
+		auth := newAuthDigest("Mufasa", "Circle of Life")
+		auth.cnonce = func() string { return "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ" }
+
+		req, err := http.NewRequest("GET", "http://www.example.org/dir/index.html", nil)
+
+		assert.Nil(t, err, "Should be nil")
+		assert.Nil(t, auth.authorize(req, challenge), "Should be nil")
+
+		params := parseAuthParams(strings.TrimPrefix(req.Header.Get("Authorization"), "Digest "))
+
+		assert.Equal(t, test.response, params["response"], "Should equal the response of the RFC example")
+		assert.Equal(t, "Mufasa", params["username"], "Should equal username")
+		assert.Equal(t, "/dir/index.html", params["uri"], "Should equal uri")
+		assert.Equal(t, "00000001", params["nc"], "Should equal nonce count")
+		assert.Equal(t, "auth", params["qop"], "Should prefer qop=auth")
+		assert.Equal(t, "FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS", params["opaque"], "Should echo opaque")
+	}
+}
+
+func TestParseDigestChallenge(t *testing.T) {
+	challenge := parseDigestChallenge([]string{
+		`Basic realm="basic", Digest realm="md5", nonce="a", algorithm=MD5`,
+		`Digest realm="sha", nonce="b", algorithm=SHA-256-sess, qop="auth-int", userhash=true`,
+	})
+
+	assert.NotNil(t, challenge, "Should not be nil")
+	assert.Equal(t, "sha", challenge.realm, "Should have picked the strongest algorithm")
+	assert.Equal(t, "SHA-256-sess", challenge.algorithm, "Should equal algorithm")
+	assert.Equal(t, []string{"auth-int"}, challenge.qop, "Should equal qop")
+	assert.True(t, challenge.userhash, "Should be true")
+
+	challenge = parseDigestChallenge([]string{`Digest realm="a, \"b\"", nonce="c"`})
+
+	assert.NotNil(t, challenge, "Should not be nil")
+	assert.Equal(t, `a, "b"`, challenge.realm, "Should have unquoted realm")
+	assert.Equal(t, "MD5", challenge.algorithm, "Should default to MD5")
+
+	assert.Nil(t, parseDigestChallenge([]string{`Basic realm="basic"`}), "Should be nil without a Digest challenge")
+	assert.Nil(t, parseDigestChallenge([]string{`Digest realm="a", nonce="b", algorithm=SHA-1`}), "Should be nil for an unsupported algorithm")
+}
+
+// digestServer checks the credentials independently of authDigest, and records the nonce counts it receives.
+type digestServer struct {
+	*httptest.Server
+	mutex     sync.Mutex
+	algorithm string
+	qop       string
+	nonce     string
+	maxUses   int
+	uses      int
+	counts    []string
+	requests  int
+}
+
+func newDigestServer(t *testing.T, algorithm, qop string, maxUses int) *digestServer {
+	s := &digestServer{algorithm: algorithm, qop: qop, nonce: "nonce-1", maxUses: maxUses}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		s.requests++
+
+		body, _ := ioutil.ReadAll(req.Body)
+		header := req.Header.Get("Authorization")
+
+		if !strings.HasPrefix(header, "Digest ") {
+			s.challenge(resp, false)
+			return
+		}
+
+		params := parseAuthParams(header[len("Digest "):])
+
+		if params["nonce"] != s.nonce {
+			s.challenge(resp, true)
+			return
+		}
+
+		if params["response"] != s.expected(req.Method, params, body) {
+			s.challenge(resp, false)
+			return
+		}
+
+		s.counts = append(s.counts, params["nc"])
+
+		if s.uses++; s.maxUses > 0 && s.uses >= s.maxUses {
+			s.nonce = fmt.Sprintf("nonce-%d", len(s.counts)+1)
+			s.uses = 0
+		}
+
+		resp.WriteHeader(http.StatusOK)
+		resp.Write(body)
+	}))
+
+	return s
+}
+
+func (s *digestServer) challenge(resp http.ResponseWriter, stale bool) {
+	resp.Header().Add("WWW-Authenticate", `Basic realm="test"`)
+	resp.Header().Add("WWW-Authenticate", fmt.Sprintf(`Digest realm="test", qop="%s", algorithm=%s, nonce="%s", opaque="opaque", stale=%t`, s.qop, s.algorithm, s.nonce, stale))
+	resp.WriteHeader(http.StatusUnauthorized)
+}
+
+func (s *digestServer) expected(method string, params map[string]string, body []byte) string {
+	newHash := md5.New
+
+	if strings.HasPrefix(s.algorithm, "SHA-256") {
+		newHash = sha256.New
+	}
+
+	h := func(parts ...string) string {
+		var hash hash.Hash = newHash()
+		hash.Write([]byte(strings.Join(parts, ":")))
+
+		return hex.EncodeToString(hash.Sum(nil))
+	}
+
+	ha1 := h("user", "test", "passwd")
+
+	if strings.HasSuffix(s.algorithm, "-sess") {
+		ha1 = h(ha1, params["nonce"], params["cnonce"])
+	}
+
+	ha2 := h(method, params["uri"])
+
+	if params["qop"] == "auth-int" {
+		ha2 = h(method, params["uri"], h(string(body)))
+	}
+
+	return h(ha1, params["nonce"], params["nc"], params["cnonce"], params["qop"], ha2)
+}
+
+func TestDigestAuthChallengeResponse(t *testing.T) {
+	for _, algorithm := range []string{"MD5", "MD5-sess", "SHA-256", "SHA-256-sess"} {
+		ts := newDigestServer(t, algorithm, "auth", 0)
+
+		req, err := NewRequestBuilder().WithUrl(ts.URL+"/dir/index.html?a=b").WithDigestAuth("user", "passwd").Build()
+
+		assert.Nil(t, err, "Should be nil")
+
+		r, err := req.Do()
+
+		assert.Nil(t, err, "Should be nil")
+		assert.Equal(t, http.StatusOK, r.StatusCode(), "Should equal HTTP Status 200 (OK) with "+algorithm)
+		assert.Equal(t, 2, ts.requests, "Should have answered the challenge")
+
+		ts.Close()
+	}
+}
+
+func TestDigestAuthIntegrity(t *testing.T) {
+	ts := newDigestServer(t, "SHA-256", "auth-int", 0)
+	defer ts.Close()
+
+	req, err := NewRequestBuilder().WithMethod("POST").WithUrl(ts.URL).WithJsonBody(`{"hello":"world"}`).WithDigestAuth("user", "passwd").Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r, err := req.Do()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, http.StatusOK, r.StatusCode(), "Should equal HTTP Status 200 (OK)")
+	assert.Equal(t, `{"hello":"world"}`, strings.TrimSpace(r.Text()), "Should have resent the body")
+}
+
+func TestDigestAuthIntegrityWithoutReplayableBody(t *testing.T) {
+	ts := newDigestServer(t, "SHA-256", "auth-int", 0)
+	defer ts.Close()
+
+	auth := NewDigestAuth("user", "passwd")
+	builder := NewRequestBuilder().WithMethod("POST").WithUrl(ts.URL).WithAuth(auth)
+
+	// The first request learns the challenge; the second one is authorized up front.
+	req, err := builder.Clone().WithJsonBody(`{"hello":"world"}`).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	_, err = req.Do()
+
+	assert.Nil(t, err, "Should be nil")
+
+	req, err = builder.Clone().WithBodyReader(ioutil.NopCloser(strings.NewReader(`{"hello":"world"}`)), "application/json").Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	_, err = req.Do()
+
+	var validationError *ValidationError
+
+	assert.True(t, errors.As(err, &validationError), "Should not have hashed an empty body")
+	assert.Equal(t, 2, ts.requests, "Should not have sent the request")
+}
+
+func TestDigestAuthNonceCount(t *testing.T) {
+	ts := newDigestServer(t, "MD5", "auth", 3)
+	defer ts.Close()
+
+	auth := NewDigestAuth("user", "passwd")
+
+	for i := 0; i < 5; i++ {
+		req, err := NewRequestBuilder().WithUrl(ts.URL).WithAuth(auth).Build()
+
+		assert.Nil(t, err, "Should be nil")
+
+		r, err := req.Do()
+
+		assert.Nil(t, err, "Should be nil")
+		assert.Equal(t, http.StatusOK, r.StatusCode(), "Should equal HTTP Status 200 (OK)")
+	}
+
+	// The nonce is replaced after 3 uses; the stale challenge restarts the count.
+	assert.Equal(t, []string{"00000001", "00000002", "00000003", "00000001", "00000002"}, ts.counts, "Should equal nonce counts")
+	assert.Equal(t, 7, ts.requests, "Should have been challenged for the first and the stale nonce only")
+}
+
+func TestDigestAuthWrongPassword(t *testing.T) {
+	ts := newDigestServer(t, "MD5", "auth", 0)
+	defer ts.Close()
+
+	req, err := NewRequestBuilder().WithUrl(ts.URL).WithDigestAuth("user", "wrong").Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r, err := req.Do()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, http.StatusUnauthorized, r.StatusCode(), "Should equal HTTP Status 401 (Unauthorized)")
+	assert.Equal(t, 2, ts.requests, "Should have answered the challenge only once")
+}
+
+func TestDigestAuthNextNonce(t *testing.T) {
+	auth := newAuthDigest("user", "passwd")
+	auth.challenges["example.org"] = &digestChallenge{nonce: "a", nonceCount: 4}
+
+	auth.nextNonce("example.org", `qop=auth, rspauth="x", cnonce="y", nc=00000004, nextnonce="b"`)
+
+	assert.Equal(t, "b", auth.challenges["example.org"].nonce, "Should equal next nonce")
+	assert.Equal(t, uint32(0), auth.challenges["example.org"].nonceCount, "Should have reset the nonce count")
+}
@@ -0,0 +1,383 @@
+package request
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// REMARKS: HTTP Message Signatures (RFC 9421). The request is signed when it is sent (see Wrap), covering the
+// components given to WithComponents; "content-digest" makes the signer compute the Content-Digest header (RFC 9530)
+// from the body first. Supported components: @method, @target-uri, @authority, @scheme, @request-target, @path,
+// @query and header fields (by their lowercase name).
+type authMessageSignature struct {
+	keyId      string
+	algorithm  signatureAlgorithm
+	label      string
+	components []string
+	expiry     time.Duration
+	tag        string
+	now        func() time.Time
+}
+
+// REMARKS: Ref: https://www.rfc-editor.org/rfc/rfc9421#section-3.3
+type signatureAlgorithm interface {
+	name() string
+	sign(base []byte) ([]byte, error)
+	verify(base, signature []byte) error
+}
+
+type hmacSha256Algorithm struct {
+	key []byte
+}
+
+type rsaPssAlgorithm struct {
+	privateKey *rsa.PrivateKey
+	publicKey  *rsa.PublicKey
+}
+
+type ed25519Algorithm struct {
+	privateKey ed25519.PrivateKey
+	publicKey  ed25519.PublicKey
+}
+
+// REMARKS: A component identifier, e.g. "@method" or "content-type", with the parameters it was serialized with.
+type signatureComponent struct {
+	name   string
+	params string
+}
+
+var errSignatureMismatch = errors.New("Signature does not match.")
+
+func newAuthMessageSignature(keyId string, algorithm signatureAlgorithm) *authMessageSignature {
+	return &authMessageSignature{
+		keyId:      keyId,
+		algorithm:  algorithm,
+		label:      defaultSignatureLabel,
+		components: defaultSignatureComponents,
+		now:        time.Now,
+	}
+}
+
+func (a *authMessageSignature) WithComponents(components ...string) MessageSignature {
+	a.components = components
+
+	return a
+}
+
+func (a *authMessageSignature) WithLabel(label string) MessageSignature {
+	a.label = label
+
+	return a
+}
+
+// REMARKS: Adds the expires parameter; the signature is rejected by verifiers once it has passed.
+func (a *authMessageSignature) WithExpiry(expiry time.Duration) MessageSignature {
+	a.expiry = expiry
+
+	return a
+}
+
+// REMARKS: An application-specific tag, which lets verifiers tell apart signatures made for different purposes.
+func (a *authMessageSignature) WithTag(tag string) MessageSignature {
+	a.tag = tag
+
+	return a
+}
+
+// REMARKS: The request is signed when it is sent (see Wrap).
+func (a *authMessageSignature) Configure(request *http.Request) {
+}
+
+func (a *authMessageSignature) Wrap(next RoundTripperFunc) RoundTripperFunc {
+	return func(request *http.Request) (*http.Response, error) {
+		if err := a.Sign(request); err != nil {
+			return nil, err
+		}
+
+		return next(request)
+	}
+}
+
+// REMARKS: Sets the Signature-Input and Signature headers (and Content-Digest, when it is covered) on the request.
+func (a *authMessageSignature) Sign(request *http.Request) error {
+	components := make([]signatureComponent, len(a.components))
+
+	for i, name := range a.components {
+		name = strings.ToLower(name)
+
+		if name == "content-digest" {
+			digest, err := contentDigest(request)
+
+			if err != nil {
+				return &MessageSignatureError{Label: a.label, Err: err}
+			}
+
+			request.Header.Set("Content-Digest", digest)
+		}
+
+		components[i] = signatureComponent{name: name}
+	}
+
+	created := a.now().Unix()
+	params := fmt.Sprintf("%s;created=%d", serializeComponents(components), created)
+
+	if a.expiry > 0 {
+		params += fmt.Sprintf(";expires=%d", created+int64(a.expiry/time.Second))
+	}
+
+	params += fmt.Sprintf(`;keyid="%s";alg="%s"`, escapeQuotes(a.keyId), a.algorithm.name())
+
+	if a.tag != "" {
+		params += fmt.Sprintf(`;tag="%s"`, escapeQuotes(a.tag))
+	}
+
+	base, err := signatureBase(request, components, params)
+
+	if err != nil {
+		return &MessageSignatureError{Label: a.label, Err: err}
+	}
+
+	signature, err := a.algorithm.sign([]byte(base))
+
+	if err != nil {
+		return &MessageSignatureError{Label: a.label, Err: err}
+	}
+
+	request.Header.Set("Signature-Input", a.label+"="+params)
+	request.Header.Set("Signature", fmt.Sprintf("%s=:%s:", a.label, base64.StdEncoding.EncodeToString(signature)))
+
+	return nil
+}
+
+// ***********************************************
+// ********** Private methods/functions **********
+// ***********************************************
+
+func (h *hmacSha256Algorithm) name() string {
+	return "hmac-sha256"
+}
+
+func (h *hmacSha256Algorithm) sign(base []byte) ([]byte, error) {
+	mac := hmac.New(sha256.New, h.key)
+	mac.Write(base)
+
+	return mac.Sum(nil), nil
+}
+
+func (h *hmacSha256Algorithm) verify(base, signature []byte) error {
+	expected, _ := h.sign(base)
+
+	if !hmac.Equal(expected, signature) {
+		return errSignatureMismatch
+	}
+
+	return nil
+}
+
+// REMARKS: Ref: https://www.rfc-editor.org/rfc/rfc9421#section-3.3.1
+var rsaPssOptions = &rsa.PSSOptions{SaltLength: 64, Hash: crypto.SHA512}
+
+func (r *rsaPssAlgorithm) name() string {
+	return "rsa-pss-sha512"
+}
+
+func (r *rsaPssAlgorithm) sign(base []byte) ([]byte, error) {
+	if r.privateKey == nil {
+		return nil, errors.New("Cannot sign without a private key.")
+	}
+
+	digest := sha512.Sum512(base)
+
+	return rsa.SignPSS(rand.Reader, r.privateKey, crypto.SHA512, digest[:], rsaPssOptions)
+}
+
+func (r *rsaPssAlgorithm) verify(base, signature []byte) error {
+	digest := sha512.Sum512(base)
+
+	if rsa.VerifyPSS(r.publicKey, crypto.SHA512, digest[:], signature, rsaPssOptions) != nil {
+		return errSignatureMismatch
+	}
+
+	return nil
+}
+
+func (e *ed25519Algorithm) name() string {
+	return "ed25519"
+}
+
+func (e *ed25519Algorithm) sign(base []byte) ([]byte, error) {
+	if e.privateKey == nil {
+		return nil, errors.New("Cannot sign without a private key.")
+	}
+
+	return ed25519.Sign(e.privateKey, base), nil
+}
+
+func (e *ed25519Algorithm) verify(base, signature []byte) error {
+	if !ed25519.Verify(e.publicKey, base, signature) {
+		return errSignatureMismatch
+	}
+
+	return nil
+}
+
+// REMARKS: Ref: https://www.rfc-editor.org/rfc/rfc9421#section-2.5
+func signatureBase(request *http.Request, components []signatureComponent, params string) (string, error) {
+	var b strings.Builder
+
+	for _, component := range components {
+		value, err := componentValue(request, component)
+
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Fprintf(&b, "\"%s\"%s: %s\n", component.name, component.params, value)
+	}
+
+	fmt.Fprintf(&b, "\"@signature-params\": %s", params)
+
+	return b.String(), nil
+}
+
+// REMARKS: Ref: https://www.rfc-editor.org/rfc/rfc9421#section-2.1 and section 2.2
+func componentValue(request *http.Request, component signatureComponent) (string, error) {
+	if component.params != "" {
+		return "", fmt.Errorf("Unsupported component parameters %q for %q.", component.params, component.name)
+	}
+
+	switch component.name {
+	case "@method":
+		return request.Method, nil
+	case "@target-uri":
+		return signatureScheme(request) + "://" + signatureAuthority(request) + request.URL.RequestURI(), nil
+	case "@authority":
+		return signatureAuthority(request), nil
+	case "@scheme":
+		return signatureScheme(request), nil
+	case "@request-target":
+		return request.URL.RequestURI(), nil
+	case "@path":
+		if path := request.URL.EscapedPath(); path != "" {
+			return path, nil
+		}
+
+		return "/", nil
+	case "@query":
+		return "?" + request.URL.RawQuery, nil
+	}
+
+	if strings.HasPrefix(component.name, "@") {
+		return "", fmt.Errorf("Unsupported derived component %q.", component.name)
+	}
+
+	values := append([]string(nil), request.Header.Values(component.name)...)
+
+	// REMARKS: net/http keeps these out of the header map on the client side.
+	switch {
+	case len(values) == 0 && component.name == "host":
+		values = []string{signatureAuthority(request)}
+	case len(values) == 0 && component.name == "content-length" && request.ContentLength > 0:
+		values = []string{strconv.FormatInt(request.ContentLength, 10)}
+	}
+
+	if len(values) == 0 {
+		return "", fmt.Errorf("The covered header %q is missing.", component.name)
+	}
+
+	for i, value := range values {
+		values[i] = strings.TrimSpace(value)
+	}
+
+	return strings.Join(values, ", "), nil
+}
+
+func signatureScheme(request *http.Request) string {
+	if request.URL.Scheme != "" {
+		return strings.ToLower(request.URL.Scheme)
+	}
+
+	if request.TLS != nil {
+		return "https"
+	}
+
+	return "http"
+}
+
+func signatureAuthority(request *http.Request) string {
+	host := request.Host
+
+	if host == "" {
+		host = request.URL.Host
+	}
+
+	host = strings.ToLower(host)
+	scheme := signatureScheme(request)
+
+	if (scheme == "http" && strings.HasSuffix(host, ":80")) || (scheme == "https" && strings.HasSuffix(host, ":443")) {
+		host = host[:strings.LastIndex(host, ":")]
+	}
+
+	return host
+}
+
+func serializeComponents(components []signatureComponent) string {
+	names := make([]string, len(components))
+
+	for i, component := range components {
+		names[i] = fmt.Sprintf("\"%s\"%s", component.name, component.params)
+	}
+
+	return "(" + strings.Join(names, " ") + ")"
+}
+
+// REMARKS: Ref: https://www.rfc-editor.org/rfc/rfc9530
+func contentDigest(request *http.Request) (string, error) {
+	hash, err := hashRequestBody(request, sha256.New)
+
+	if err != nil {
+		return "", err
+	}
+
+	return "sha-256=:" + base64.StdEncoding.EncodeToString(hash) + ":", nil
+}
+
+func hashRequestBody(request *http.Request, newHash func() hash.Hash) ([]byte, error) {
+	hash := newHash()
+
+	if request.Body == nil || request.Body == http.NoBody {
+		return hash.Sum(nil), nil
+	}
+
+	if request.GetBody == nil {
+		return nil, errors.New("Cannot compute the digest of a body that can only be read once.")
+	}
+
+	body, err := request.GetBody()
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer body.Close()
+
+	if _, err := io.Copy(hash, body); err != nil {
+		return nil, err
+	}
+
+	return hash.Sum(nil), nil
+}
@@ -0,0 +1,266 @@
+package request
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The test request of RFC 9421, appendix B.2.
+func newRFC9421Request(t *testing.T) *http.Request {
+	req, err := http.NewRequest("POST", "http://example.com/foo?param=Value&Pet=dog", strings.NewReader(`{"hello": "world"}`))
+
+	assert.Nil(t, err, "Should be nil")
+
+	req.Header.Set("Date", "Tue, 20 Apr 2021 02:07:55 GMT")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Digest", "sha-512=:WZDPaVn/7XgHaAy8pmojAkGWoRx2UFChF41A2svX+TaPm+AbwAgBWnrIiYllu7BNNyealdVLvRwEmTHWXvJwew==:")
+
+	return req
+}
+
+func decodeBase64(t *testing.T, s string) []byte {
+	b, err := base64.StdEncoding.DecodeString(s)
+
+	assert.Nil(t, err, "Should be nil")
+
+	return b
+}
+
+// Ref: https://www.rfc-editor.org/rfc/rfc9421#appendix-B.2.5
+func TestMessageVerifierRFC9421Hmac(t *testing.T) {
+	key := decodeBase64(t, "uzvJfB4u3N0Jy4T7NZ75MDVcr8zSTInedJtkgcu46YW4XByzNJjxBdtjUkdJPBtbmHhIDi6pcl8jsasjlTMtDQ==")
+
+	req := newRFC9421Request(t)
+	req.Header.Set("Signature-Input", `sig-b25=("date" "@authority" "content-type");created=1618884473;keyid="test-shared-secret"`)
+	req.Header.Set("Signature", "sig-b25=:pxcQw6G3AjtMBQjwo8XzkZf/bws5LelbaMk5rGIGtE8=:")
+
+	// The test vector doesn't cover the method and the path.
+	verifier := NewMessageVerifier(func(keyId string) (interface{}, error) {
+		assert.Equal(t, "test-shared-secret", keyId, "Should equal key id")
+
+		return key, nil
+	}).WithRequiredComponents("@authority")
+
+	assert.Nil(t, verifier.Verify(req), "Should be nil")
+
+	req.Header.Set("Content-Type", "text/plain")
+
+	assert.NotNil(t, verifier.Verify(req), "Should have detected the modified header")
+}
+
+// Ref: https://www.rfc-editor.org/rfc/rfc9421#appendix-B.2.6
+func TestMessageSignatureRFC9421Ed25519(t *testing.T) {
+	parsed, err := x509.ParsePKCS8PrivateKey(decodeBase64(t, "MC4CAQAwBQYDK2VwBCIEIJ+DYvh6SEqVTm50DFtMDoQikTmiCqirVv9mWG9qfSnF"))
+
+	assert.Nil(t, err, "Should be nil")
+
+	key := parsed.(ed25519.PrivateKey)
+	expected := "wqcAqbmYJ2ji2glfAMaRy4gruYYnx2nEFN2HN6jrnDnQCK1u02Gb04v9EDgwUPiu4A0w6vuQv5lIp5WPpBKRCw=="
+
+	req := newRFC9421Request(t)
+	req.Header.Set("Content-Length", "18")
+	req.Header.Set("Signature-Input", `sig-b26=("date" "@method" "@path" "@authority" "content-type" "content-length");created=1618884473;keyid="test-key-ed25519"`)
+	req.Header.Set("Signature", "sig-b26=:"+expected+":")
+
+	verifier := NewMessageVerifier(func(keyId string) (interface{}, error) {
+		return key.Public(), nil
+	})
+
+	assert.Nil(t, verifier.Verify(req), "Should be nil")
+
+	// Ed25519 signatures are deterministic, so the signer must produce the same one (with an alg parameter added).
+	signer := newAuthMessageSignature("test-key-ed25519", &ed25519Algorithm{privateKey: key, publicKey: key.Public().(ed25519.PublicKey)})
+	signer.now = func() time.Time { return time.Unix(1618884473, 0) }
+	signer.WithComponents("date", "@method", "@path", "@authority", "content-type", "content-length").WithLabel("sig-b26")
+
+	req = newRFC9421Request(t)
+
+	assert.Nil(t, signer.Sign(req), "Should be nil")
+	assert.Equal(t, `sig-b26=("date" "@method" "@path" "@authority" "content-type" "content-length");created=1618884473;keyid="test-key-ed25519";alg="ed25519"`, req.Header.Get("Signature-Input"), "Should equal signature input")
+	assert.Nil(t, verifier.Verify(req), "Should be nil")
+}
+
+func TestMessageSignatureRoundTrip(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+
+	assert.Nil(t, err, "Should be nil")
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+
+	assert.Nil(t, err, "Should be nil")
+
+	keys := map[string]interface{}{
+		"hmac":    []byte("secret"),
+		"rsa":     &rsaKey.PublicKey,
+		"ed25519": edKey.Public(),
+	}
+
+	signers := map[string]MessageSignature{
+		"hmac":    NewHmacSignature("hmac", []byte("secret")),
+		"rsa":     NewRsaPssSignature("rsa", rsaKey),
+		"ed25519": NewEd25519Signature("ed25519", edKey),
+	}
+
+	verifier := NewMessageVerifier(func(keyId string) (interface{}, error) {
+		if key, ok := keys[keyId]; ok {
+			return key, nil
+		}
+
+		return nil, fmt.Errorf("unknown key %q", keyId)
+	}).WithRequiredComponents("@method", "@path", "content-digest").WithMaxAge(time.Minute)
+
+	ts := httptest.NewServer(verifier.Handler(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+
+		resp.WriteHeader(http.StatusOK)
+		resp.Write(body)
+	})))
+	defer ts.Close()
+
+	for name, signer := range signers {
+		signer.WithComponents("@method", "@target-uri", "@path", "@query", "content-type", "content-digest").WithExpiry(time.Minute).WithTag("test")
+
+		req, err := NewRequestBuilder().WithMethod("POST").WithUrl(ts.URL + "/api/items?page=2").WithJsonBody(`{"name":"item"}`).WithAuth(signer).Build()
+
+		assert.Nil(t, err, "Should be nil")
+
+		r, err := req.Do()
+
+		assert.Nil(t, err, "Should be nil")
+		assert.Equal(t, http.StatusOK, r.StatusCode(), "Should have verified the "+name+" signature: "+r.Text())
+		assert.Equal(t, `{"name":"item"}`, r.Text(), "Should have left the body readable")
+	}
+
+	// Signed without covering the body.
+	req, err := NewRequestBuilder().WithMethod("POST").WithUrl(ts.URL).WithTextBody("Hello World").WithAuth(NewHmacSignature("hmac", []byte("secret"))).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r, err := req.Do()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, http.StatusUnauthorized, r.StatusCode(), "Should have rejected the signature without the required components")
+
+	// Signed with an unknown key.
+	req, err = NewRequestBuilder().WithUrl(ts.URL).WithAuth(NewHmacSignature("other", []byte("secret"))).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r, err = req.Do()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, http.StatusUnauthorized, r.StatusCode(), "Should have rejected the unknown key")
+}
+
+func TestMessageVerifierRejectsTampering(t *testing.T) {
+	signer := NewHmacSignature("hmac", []byte("secret")).WithComponents("@method", "@path", "content-digest")
+	verifier := NewMessageVerifier(func(keyId string) (interface{}, error) { return []byte("secret"), nil }).WithRequiredComponents("@method", "@path")
+
+	req, err := http.NewRequest("PUT", "http://example.com/items/1", strings.NewReader("original"))
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Nil(t, signer.Sign(req), "Should be nil")
+	assert.Equal(t, "sha-256=:"+base64.StdEncoding.EncodeToString(hashBytes("original"))+":", req.Header.Get("Content-Digest"), "Should equal content digest")
+	assert.Nil(t, verifier.Verify(req), "Should be nil")
+
+	req.Body = ioutil.NopCloser(strings.NewReader("tampered"))
+
+	var e *MessageSignatureError
+
+	err = verifier.Verify(req)
+
+	assert.True(t, errors.As(err, &e), "Should be a MessageSignatureError")
+	assert.Equal(t, "sig1", e.Label, "Should equal label")
+
+	req.Body = ioutil.NopCloser(strings.NewReader("original"))
+	req.URL.Path = "/items/2"
+
+	assert.NotNil(t, verifier.Verify(req), "Should have detected the modified path")
+
+	assert.NotNil(t, verifier.Verify(httptest.NewRequest("GET", "/", nil)), "Should reject requests that are not signed")
+}
+
+func TestMessageVerifierRequiredComponents(t *testing.T) {
+	verifier := NewMessageVerifier(func(keyId string) (interface{}, error) { return []byte("secret"), nil })
+
+	for components, verified := range map[string]bool{"": false, "@method @path": false, "@method @authority @path": true} {
+		req, err := http.NewRequest("GET", "http://example.com/", nil)
+
+		assert.Nil(t, err, "Should be nil")
+		assert.Nil(t, NewHmacSignature("hmac", []byte("secret")).WithComponents(strings.Fields(components)...).Sign(req), "Should be nil")
+		assert.Equal(t, verified, verifier.Verify(req) == nil, "Should require the default components, not "+components)
+	}
+
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Nil(t, NewHmacSignature("hmac", []byte("secret")).WithComponents().Sign(req), "Should be nil")
+	assert.Nil(t, verifier.WithRequiredComponents().Verify(req), "Should accept any signature when relaxed explicitly")
+}
+
+func TestMessageVerifierMaxBodySize(t *testing.T) {
+	signer := NewHmacSignature("hmac", []byte("secret")).WithComponents("@method", "@authority", "@path", "content-digest")
+	verifier := NewMessageVerifier(func(keyId string) (interface{}, error) { return []byte("secret"), nil }).WithMaxBodySize(8)
+
+	for body, verified := range map[string]bool{"12345678": true, "123456789": false} {
+		req, err := http.NewRequest("POST", "http://example.com/", strings.NewReader(body))
+
+		assert.Nil(t, err, "Should be nil")
+		assert.Nil(t, signer.Sign(req), "Should be nil")
+		assert.Equal(t, verified, verifier.Verify(req) == nil, "Should have limited the size of the body "+body)
+	}
+}
+
+func TestMessageVerifierExpiry(t *testing.T) {
+	signer := newAuthMessageSignature("hmac", &hmacSha256Algorithm{key: []byte("secret")})
+	signer.WithExpiry(time.Minute)
+
+	verifier := newMessageVerifier(func(keyId string) (interface{}, error) { return []byte("secret"), nil })
+
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Nil(t, signer.Sign(req), "Should be nil")
+	assert.Nil(t, verifier.Verify(req), "Should be nil")
+
+	verifier.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+
+	assert.NotNil(t, verifier.Verify(req), "Should have rejected the expired signature")
+
+	verifier.now = func() time.Time { return time.Now().Add(30 * time.Second) }
+	verifier.WithMaxAge(10 * time.Second)
+
+	assert.NotNil(t, verifier.Verify(req), "Should have rejected the old signature")
+}
+
+func TestMessageSignatureMissingHeader(t *testing.T) {
+	req, err := NewRequestBuilder().WithUrl("http://example.com").WithAuth(NewHmacSignature("hmac", []byte("secret")).WithComponents("x-missing")).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	_, err = req.Do()
+
+	var e *MessageSignatureError
+
+	assert.True(t, errors.As(err, &e), "Should be a MessageSignatureError")
+}
+
+func hashBytes(s string) []byte {
+	h := sha256.Sum256([]byte(s))
+
+	return h[:]
+}
@@ -0,0 +1,288 @@
+package request
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// REMARKS: OAuth 2.0 token acquisition (RFC 6749) for the client credentials, password and refresh token grants.
+// Tokens are fetched when the first request is sent, cached until shortly before they expire, and refreshed with
+// the refresh token when the server issued one. A 401 response invalidates the cached token, and the request is
+// sent once more with a new one.
+type authOAuth2 struct {
+	mutex         sync.Mutex
+	tokenUrl      string
+	clientId      string
+	clientSecret  string
+	grant         url.Values
+	scopes        []string
+	bodyAuth      bool
+	httpClient    *http.Client
+	leeway        time.Duration
+	now           func() time.Time
+	token         *oauth2Token
+	refreshToken  string
+	refreshedOnly bool
+}
+
+// REMARKS: A zero expiry means the server didn't say when the token expires; it is then used until it is rejected.
+type oauth2Token struct {
+	accessToken string
+	tokenType   string
+	expiry      time.Time
+}
+
+// REMARKS: Ref: https://www.rfc-editor.org/rfc/rfc6749#section-5.1
+type oauth2TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+func newAuthOAuth2(tokenUrl, clientId, clientSecret string, grant url.Values) *authOAuth2 {
+	return &authOAuth2{
+		tokenUrl:     tokenUrl,
+		clientId:     clientId,
+		clientSecret: clientSecret,
+		grant:        grant,
+		leeway:       defaultOAuth2Leeway,
+		now:          time.Now,
+	}
+}
+
+func (a *authOAuth2) WithScopes(scopes ...string) OAuth2 {
+	a.scopes = scopes
+
+	return a
+}
+
+// REMARKS: By default the client authenticates with HTTP Basic (RFC 6749, section 2.3.1); some providers only accept the body.
+func (a *authOAuth2) WithClientCredentialsInBody() OAuth2 {
+	a.bodyAuth = true
+
+	return a
+}
+
+func (a *authOAuth2) WithHttpClient(client *http.Client) OAuth2 {
+	a.httpClient = client
+
+	return a
+}
+
+// REMARKS: The token is applied when the request is sent (see Wrap), so Build never has to reach the token endpoint.
+func (a *authOAuth2) Configure(request *http.Request) {
+}
+
+func (a *authOAuth2) Wrap(next RoundTripperFunc) RoundTripperFunc {
+	return func(request *http.Request) (*http.Response, error) {
+		token, err := a.current(request.Context())
+
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := next(a.authorize(request, token))
+
+		if err != nil || resp.StatusCode != http.StatusUnauthorized || !canResend(request) {
+			return resp, err
+		}
+
+		// REMARKS: The token may have been revoked before it expired; try once more with a new one.
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+
+		a.invalidate(token)
+
+		token, err = a.current(request.Context())
+
+		if err != nil {
+			return nil, err
+		}
+
+		retry, err := resend(request)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return next(a.authorize(retry, token))
+	}
+}
+
+// REMARKS: Returns the cached access token, fetching a new one first if there is none or it is about to expire.
+// Concurrent callers wait for a single fetch instead of each hitting the token endpoint.
+func (a *authOAuth2) Token(ctx context.Context) (string, error) {
+	token, err := a.current(ctx)
+
+	if err != nil {
+		return "", err
+	}
+
+	return token.accessToken, nil
+}
+
+// ***********************************************
+// ********** Private methods/functions **********
+// ***********************************************
+
+func (a *authOAuth2) current(ctx context.Context) (*oauth2Token, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.token != nil && (a.token.expiry.IsZero() || a.now().Before(a.token.expiry)) {
+		return a.token, nil
+	}
+
+	token, err := a.fetch(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	a.token = token
+
+	return token, nil
+}
+
+// REMARKS: Prefers the refresh token when there is one; if the server rejects it, falls back to the original grant
+// (unless the refresh token is all there is).
+func (a *authOAuth2) fetch(ctx context.Context) (*oauth2Token, error) {
+	if a.refreshToken != "" {
+		token, err := a.request(ctx, url.Values{
+			"grant_type":    []string{"refresh_token"},
+			"refresh_token": []string{a.refreshToken},
+		})
+
+		if err == nil || a.refreshedOnly {
+			return token, err
+		}
+
+		a.refreshToken = ""
+	}
+
+	return a.request(ctx, a.grant)
+}
+
+func (a *authOAuth2) request(ctx context.Context, grant url.Values) (*oauth2Token, error) {
+	values := url.Values{}
+
+	for k, v := range grant {
+		values[k] = v
+	}
+
+	if len(a.scopes) > 0 {
+		values.Set("scope", strings.Join(a.scopes, " "))
+	}
+
+	builder := NewRequestBuilder().WithMethod("POST").WithUrl(a.tokenUrl).WithContext(ctx).WithHeader("Accept", "application/json")
+
+	if a.httpClient != nil {
+		builder.WithHttpClient(a.httpClient)
+	}
+
+	if a.bodyAuth {
+		values.Set("client_id", a.clientId)
+		values.Set("client_secret", a.clientSecret)
+	} else {
+		builder.WithBasicAuth(url.QueryEscape(a.clientId), url.QueryEscape(a.clientSecret))
+	}
+
+	var result oauth2TokenResponse
+	var errResult OAuth2Error
+
+	req, err := builder.WithFormBody(values).WithResult(&result).WithErrorResult(&errResult).Build()
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := req.Do()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !resp.IsSuccess() {
+		errResult.StatusCode = resp.StatusCode()
+
+		return nil, &errResult
+	}
+
+	if result.AccessToken == "" {
+		return nil, &OAuth2Error{StatusCode: resp.StatusCode(), Code: "invalid_response", Description: "The token response has no access_token."}
+	}
+
+	if result.RefreshToken != "" {
+		a.refreshToken = result.RefreshToken
+	}
+
+	token := &oauth2Token{
+		accessToken: result.AccessToken,
+		tokenType:   result.TokenType,
+	}
+
+	if result.ExpiresIn > 0 {
+		lifetime := time.Duration(result.ExpiresIn) * time.Second
+		leeway := a.leeway
+
+		// REMARKS: A short-lived token would otherwise expire as soon as it is issued, and be fetched again on every request.
+		if leeway > lifetime/2 {
+			leeway = lifetime / 2
+		}
+
+		token.expiry = a.now().Add(lifetime - leeway)
+	}
+
+	return token, nil
+}
+
+// REMARKS: Only drops the token if it is still the cached one; another request may already have replaced it.
+func (a *authOAuth2) invalidate(token *oauth2Token) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.token == token {
+		a.token = nil
+	}
+}
+
+func (a *authOAuth2) authorize(request *http.Request, token *oauth2Token) *http.Request {
+	tokenType := token.tokenType
+
+	if tokenType == "" || strings.EqualFold(tokenType, "bearer") {
+		tokenType = "Bearer"
+	}
+
+	request.Header.Set("Authorization", fmt.Sprintf("%s %s", tokenType, token.accessToken))
+
+	return request
+}
+
+// REMARKS: A request can be sent again by an authorization method if it has no body, or its body can be recreated.
+func canResend(request *http.Request) bool {
+	return request.Body == nil || request.Body == http.NoBody || request.GetBody != nil
+}
+
+func resend(request *http.Request) (*http.Request, error) {
+	retry := request.Clone(request.Context())
+
+	if request.GetBody != nil {
+		body, err := request.GetBody()
+
+		if err != nil {
+			return nil, err
+		}
+
+		retry.Body = body
+	}
+
+	return retry, nil
+}
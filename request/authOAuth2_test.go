@@ -0,0 +1,292 @@
+package request
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTokenServer issues "token-1", "token-2", ... and counts the calls made to it per grant type.
+func newTokenServer(t *testing.T, expiresIn int) (*httptest.Server, map[string]*int32) {
+	var issued int32
+
+	calls := map[string]*int32{
+		"client_credentials": new(int32),
+		"password":           new(int32),
+		"refresh_token":      new(int32),
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		assert.Nil(t, req.ParseForm(), "Should be nil")
+
+		grant := req.PostForm.Get("grant_type")
+
+		if counter, ok := calls[grant]; ok {
+			atomic.AddInt32(counter, 1)
+		}
+
+		resp.Header().Set("Content-Type", "application/json")
+
+		if username, password, _ := req.BasicAuth(); username != "client" || password != "secret" {
+			resp.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprintf(resp, `{"error":"invalid_client","error_description":"Bad client credentials."}`)
+			return
+		}
+
+		if grant == "refresh_token" && req.PostForm.Get("refresh_token") != "refresh" {
+			resp.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(resp, `{"error":"invalid_grant"}`)
+			return
+		}
+
+		n := atomic.AddInt32(&issued, 1)
+
+		fmt.Fprintf(resp, `{"access_token":"token-%d","token_type":"bearer","expires_in":%d,"refresh_token":"refresh"}`, n, expiresIn)
+	}))
+
+	return ts, calls
+}
+
+// newProtectedServer only accepts the tokens for which valid returns true.
+func newProtectedServer(valid func(token string) bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		b, _ := ioutil.ReadAll(req.Body)
+
+		token := req.Header.Get("Authorization")
+
+		if len(token) < 7 || !valid(token[7:]) {
+			resp.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		resp.WriteHeader(http.StatusOK)
+		fmt.Fprintf(resp, "%s %s", token[7:], string(b))
+	}))
+}
+
+func TestOAuth2ClientCredentials(t *testing.T) {
+	tokenServer, calls := newTokenServer(t, 3600)
+	defer tokenServer.Close()
+
+	ts := newProtectedServer(func(token string) bool { return token == "token-1" })
+	defer ts.Close()
+
+	auth := NewOAuth2ClientCredentials(tokenServer.URL, "client", "secret").WithScopes("read", "write")
+
+	for i := 0; i < 3; i++ {
+		req, err := NewRequestBuilder().WithUrl(ts.URL).WithAuth(auth).Build()
+
+		assert.Nil(t, err, "Should be nil")
+		assert.Empty(t, req.getUnderlyingRequest().Header.Get("Authorization"), "Should not fetch a token at Build time")
+
+		r, err := req.Do()
+
+		assert.Nil(t, err, "Should be nil")
+		assert.Equal(t, http.StatusOK, r.StatusCode(), "Should equal HTTP Status 200 (OK)")
+	}
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls["client_credentials"]), "Should have cached the token")
+}
+
+func TestOAuth2RefreshesExpiredToken(t *testing.T) {
+	tokenServer, calls := newTokenServer(t, 60)
+	defer tokenServer.Close()
+
+	auth := NewOAuth2Password(tokenServer.URL, "client", "secret", "user", "pass").(*authOAuth2)
+
+	now := time.Now()
+	auth.now = func() time.Time { return now }
+
+	token, err := auth.Token(context.Background())
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, "token-1", token, "Should equal first token")
+
+	now = now.Add(51 * time.Second)
+
+	token, err = auth.Token(context.Background())
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, "token-2", token, "Should have refreshed the token before it expired")
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls["password"]), "Should have used the password grant once")
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls["refresh_token"]), "Should have used the refresh token")
+}
+
+func TestOAuth2ShortLivedToken(t *testing.T) {
+	tokenServer, calls := newTokenServer(t, 10)
+	defer tokenServer.Close()
+
+	auth := NewOAuth2ClientCredentials(tokenServer.URL, "client", "secret").(*authOAuth2)
+
+	now := time.Now()
+	auth.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		token, err := auth.Token(context.Background())
+
+		assert.Nil(t, err, "Should be nil")
+		assert.Equal(t, "token-1", token, "Should have reused the token within half of its lifetime")
+	}
+
+	now = now.Add(5 * time.Second)
+
+	token, err := auth.Token(context.Background())
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, "token-2", token, "Should have fetched a new token after half of its lifetime")
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls["client_credentials"]), "Should have used the client credentials once")
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls["refresh_token"]), "Should have used the refresh token")
+}
+
+func TestOAuth2RefreshToken(t *testing.T) {
+	tokenServer, calls := newTokenServer(t, 3600)
+	defer tokenServer.Close()
+
+	token, err := NewOAuth2RefreshToken(tokenServer.URL, "client", "secret", "refresh").Token(context.Background())
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, "token-1", token, "Should equal token")
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls["refresh_token"]), "Should have used the refresh token")
+
+	_, err = NewOAuth2RefreshToken(tokenServer.URL, "client", "secret", "revoked").Token(context.Background())
+
+	e, ok := err.(*OAuth2Error)
+
+	assert.True(t, ok, "Should be an OAuth2Error")
+	assert.Equal(t, "invalid_grant", e.Code, "Should equal error code")
+	assert.Equal(t, http.StatusBadRequest, e.StatusCode, "Should equal HTTP Status 400 (Bad Request)")
+}
+
+func TestOAuth2RetriesOnceOnUnauthorized(t *testing.T) {
+	tokenServer, calls := newTokenServer(t, 3600)
+	defer tokenServer.Close()
+
+	// The first token is "revoked" by the server before it expires.
+	ts := newProtectedServer(func(token string) bool { return token != "token-1" })
+	defer ts.Close()
+
+	auth := NewOAuth2ClientCredentials(tokenServer.URL, "client", "secret")
+
+	req, err := NewRequestBuilder().WithMethod("POST").WithUrl(ts.URL).WithTextBody("Hello World").WithAuth(auth).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r, err := req.Do()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, http.StatusOK, r.StatusCode(), "Should equal HTTP Status 200 (OK)")
+	assert.Equal(t, "token-2 Hello World", r.Text(), "Should have resent the body with the new token")
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls["refresh_token"]), "Should have fetched a new token")
+
+	ts2 := newProtectedServer(func(token string) bool { return false })
+	defer ts2.Close()
+
+	req, err = NewRequestBuilder().WithUrl(ts2.URL).WithAuth(auth).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r, err = req.Do()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, http.StatusUnauthorized, r.StatusCode(), "Should have retried only once")
+}
+
+func TestOAuth2InvalidClient(t *testing.T) {
+	tokenServer, _ := newTokenServer(t, 3600)
+	defer tokenServer.Close()
+
+	auth := NewOAuth2ClientCredentials(tokenServer.URL, "client", "wrong")
+
+	req, err := NewRequestBuilder().WithUrl(POSTMAN_ECHO_ROOT).WithAuth(auth).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r, err := req.Do()
+
+	assert.Nil(t, r, "Should be nil")
+
+	var e *OAuth2Error
+
+	assert.True(t, errors.As(err, &e), "Should be an OAuth2Error")
+	assert.Equal(t, "invalid_client", e.Code, "Should equal error code")
+	assert.Equal(t, "Bad client credentials.", e.Description, "Should equal error description")
+}
+
+func TestOAuth2ClientCredentialsInBody(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		assert.Nil(t, req.ParseForm(), "Should be nil")
+		assert.Empty(t, req.Header.Get("Authorization"), "Should not have used basic authentication")
+		assert.Equal(t, "client", req.PostForm.Get("client_id"), "Should equal client id")
+		assert.Equal(t, "secret", req.PostForm.Get("client_secret"), "Should equal client secret")
+		assert.Equal(t, "read write", req.PostForm.Get("scope"), "Should equal scope")
+
+		resp.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(resp, `{"access_token":"token","token_type":"Bearer"}`)
+	}))
+	defer ts.Close()
+
+	token, err := NewOAuth2ClientCredentials(ts.URL, "client", "secret").WithClientCredentialsInBody().WithScopes("read", "write").Token(context.Background())
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, "token", token, "Should equal token")
+}
+
+func TestOAuth2ConcurrentRequestsFetchOnce(t *testing.T) {
+	tokenServer, calls := newTokenServer(t, 3600)
+	defer tokenServer.Close()
+
+	ts := newProtectedServer(func(token string) bool { return token == "token-1" })
+	defer ts.Close()
+
+	auth := NewOAuth2ClientCredentials(tokenServer.URL, "client", "secret")
+	template := NewRequestBuilder().WithUrl(ts.URL).WithAuth(auth).Template()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 25; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			req, err := template.NewRequestBuilder().Build()
+
+			assert.Nil(t, err, "Should be nil")
+
+			r, err := req.Do()
+
+			assert.Nil(t, err, "Should be nil")
+			assert.Equal(t, http.StatusOK, r.StatusCode(), "Should equal HTTP Status 200 (OK)")
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls["client_credentials"]), "Should have fetched a single token")
+}
+
+func TestOAuth2BasicAuthEncoding(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		expected := "Basic " + base64.StdEncoding.EncodeToString([]byte("my+client:s%3Acret"))
+
+		assert.Equal(t, expected, req.Header.Get("Authorization"), "Should have form-encoded the client credentials")
+
+		resp.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(resp, `{"access_token":"token"}`)
+	}))
+	defer ts.Close()
+
+	_, err := NewOAuth2ClientCredentials(ts.URL, "my client", "s:cret").Token(context.Background())
+
+	assert.Nil(t, err, "Should be nil")
+}
@@ -3,6 +3,7 @@ package request
 import (
 	"encoding/base64"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -45,3 +46,70 @@ func TestBearerAuth(t *testing.T) {
 
 	assert.Equal(t, bearerAuthString, req.Header.Get("Authorization"), "Should equal authorization header value")
 }
+
+func TestApiKeyAuth(t *testing.T) {
+	req, err := http.NewRequest("GET", POSTMAN_ECHO_ROOT+"?page=1", nil)
+
+	assert.Nil(t, err, "Should be nil")
+
+	NewApiKeyAuth("X-API-Key", TEST_TOKEN, ApiKeyInHeader).Configure(req)
+	NewApiKeyAuth("api key", "a&b", ApiKeyInQuery).Configure(req)
+	NewApiKeyAuth("session", TEST_TOKEN, ApiKeyInCookie).Configure(req)
+
+	assert.Equal(t, TEST_TOKEN, req.Header.Get("X-API-Key"), "Should equal API key header value")
+	assert.Equal(t, "page=1&api+key=a%26b", req.URL.RawQuery, "Should have appended the API key to the query")
+
+	cookie, err := req.Cookie("session")
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, TEST_TOKEN, cookie.Value, "Should equal API key cookie value")
+}
+
+// customAuth stands for a scheme implemented outside of the package.
+type customAuth struct {
+	token string
+}
+
+func (a *customAuth) Configure(request *http.Request) {
+	request.Header.Set("Authorization", "Token "+a.token)
+}
+
+func TestWithAuthCustomMethod(t *testing.T) {
+	req, err := NewRequestBuilder().WithUrl(POSTMAN_ECHO_ROOT).WithAuth(&customAuth{token: TEST_TOKEN}).Build()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, "Token "+TEST_TOKEN, req.getUnderlyingRequest().Header.Get("Authorization"), "Should equal authorization header value")
+
+	req, err = NewRequestBuilder().WithUrl(POSTMAN_ECHO_ROOT).WithBearerAuth(TEST_TOKEN).WithAuth(nil).Build()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Empty(t, req.getUnderlyingRequest().Header.Get("Authorization"), "Should have removed the authorization")
+}
+
+func TestWithAuthChain(t *testing.T) {
+	verifier := NewMessageVerifier(func(keyId string) (interface{}, error) {
+		return []byte("secret"), nil
+	}).WithRequiredComponents("x-api-key", "authorization")
+
+	ts := httptest.NewServer(verifier.Handler(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "key", req.Header.Get("X-API-Key"), "Should equal API key header value")
+		assert.Equal(t, "Bearer "+TEST_TOKEN, req.Header.Get("Authorization"), "Should equal authorization header value")
+
+		resp.WriteHeader(http.StatusOK)
+	})))
+	defer ts.Close()
+
+	// The signature runs last, so it covers the headers set by the other methods.
+	req, err := NewRequestBuilder().WithUrl(ts.URL).WithAuth(
+		NewApiKeyAuth("X-API-Key", "key", ApiKeyInHeader),
+		NewBearerAuth(TEST_TOKEN),
+		NewHmacSignature("hmac", []byte("secret")).WithComponents("@method", "x-api-key", "authorization"),
+	).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r, err := req.Do()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, http.StatusOK, r.StatusCode(), "Should equal HTTP Status 200 (OK)")
+}
@@ -0,0 +1,563 @@
+package request
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// REMARKS: A private HTTP cache (RFC 9111) in front of the transport, for GET requests. Fresh responses are served
+// from the store; stale ones are revalidated with a conditional request (If-None-Match / If-Modified-Since), or served
+// as they are while being revalidated in the background when the response allows it (stale-while-revalidate).
+// Successful unsafe requests (POST, PUT, ...) invalidate the stored response for their URL. Every response that goes
+// through the cache carries a Cache-Status header (RFC 9211), see Response.CacheStatus. Revalidations holds the
+// background revalidations in progress, so that a URL is only revalidated once at a time.
+type httpCache struct {
+	store         CacheStore
+	authorized    bool
+	revalidations *sync.Map
+	now           func() time.Time
+}
+
+// REMARKS: The stored form of a response. Vary holds the values the request had for the headers listed in the
+// response's Vary header; only the last variant of a URL is stored.
+type cacheEntry struct {
+	StatusCode   int               `json:"status"`
+	Header       http.Header       `json:"header"`
+	Body         []byte            `json:"body"`
+	Vary         map[string]string `json:"vary,omitempty"`
+	RequestTime  time.Time         `json:"requestTime"`
+	ResponseTime time.Time         `json:"responseTime"`
+}
+
+const cacheStatusName = "gorequest"
+
+// REMARKS: Ref: https://www.rfc-editor.org/rfc/rfc9110#section-15.1
+var heuristicallyCacheable = map[int]bool{
+	200: true, 203: true, 204: true, 300: true, 301: true, 308: true, 404: true, 405: true, 410: true, 414: true, 501: true,
+}
+
+func newHttpCache(store CacheStore) *httpCache {
+	return &httpCache{
+		store:         store,
+		revalidations: &sync.Map{},
+		now:           time.Now,
+	}
+}
+
+// REMARKS: A copy for the requests of one builder, sharing the store and the background revalidations.
+func (c *httpCache) withAuthorization(authorized bool) *httpCache {
+	cache := *c
+	cache.authorized = authorized
+
+	return &cache
+}
+
+func (c *httpCache) Wrap(next RoundTripperFunc) RoundTripperFunc {
+	return func(request *http.Request) (*http.Response, error) {
+		key := request.URL.String()
+
+		if request.Method != http.MethodGet {
+			resp, err := next(request)
+
+			if err == nil && request.Method != http.MethodHead && request.Method != http.MethodOptions && request.Method != http.MethodTrace && resp.StatusCode < 400 {
+				c.store.Delete(key)
+			}
+
+			return resp, err
+		}
+
+		requestControl := parseCacheControl(request.Header.Values("Cache-Control"))
+
+		// REMARKS: Requests with their own conditionals or ranges are left to the caller.
+		if hasAny(request.Header, "Range", "If-None-Match", "If-Modified-Since", "If-Match", "If-Unmodified-Since") {
+			return next(request)
+		}
+
+		if requestControl.has("no-store") {
+			return c.forward(next, request, key, "request", false)
+		}
+
+		entry := c.lookup(key, request)
+
+		if entry == nil {
+			if requestControl.has("only-if-cached") {
+				return c.gatewayTimeout(request), nil
+			}
+
+			return c.forward(next, request, key, "uri-miss", true)
+		}
+
+		responseControl := parseCacheControl(entry.Header.Values("Cache-Control"))
+		now := c.now()
+		age := entry.age(now)
+		lifetime := entry.freshnessLifetime()
+
+		if c.fresh(requestControl, responseControl, age, lifetime) {
+			return c.serve(request, entry, now, fmt.Sprintf("hit; ttl=%d", int64((lifetime-age)/time.Second))), nil
+		}
+
+		if requestControl.has("only-if-cached") {
+			return c.gatewayTimeout(request), nil
+		}
+
+		if window, ok := responseControl.duration("stale-while-revalidate"); ok && age-lifetime <= window && !requestControl.has("no-cache") && !responseControl.has("no-cache") && !responseControl.has("must-revalidate") {
+			c.revalidateInBackground(next, request, key, entry)
+
+			return c.serve(request, entry, now, fmt.Sprintf("hit; ttl=%d; detail=stale-while-revalidate", int64((lifetime-age)/time.Second))), nil
+		}
+
+		return c.revalidate(next, request, key, entry)
+	}
+}
+
+// ***********************************************
+// ********** Private methods/functions **********
+// ***********************************************
+
+func (c *httpCache) lookup(key string, request *http.Request) *cacheEntry {
+	data, ok := c.store.Get(key)
+
+	if !ok {
+		return nil
+	}
+
+	var entry cacheEntry
+
+	if json.Unmarshal(data, &entry) != nil {
+		c.store.Delete(key)
+
+		return nil
+	}
+
+	for name, value := range entry.Vary {
+		if strings.Join(request.Header.Values(name), ", ") != value {
+			return nil
+		}
+	}
+
+	return &entry
+}
+
+func (c *httpCache) fresh(requestControl, responseControl cacheControl, age, lifetime time.Duration) bool {
+	if requestControl.has("no-cache") || responseControl.has("no-cache") {
+		return false
+	}
+
+	if maxAge, ok := requestControl.duration("max-age"); ok && age > maxAge {
+		return false
+	}
+
+	if minFresh, ok := requestControl.duration("min-fresh"); ok {
+		age += minFresh
+	}
+
+	if age < lifetime {
+		return true
+	}
+
+	// REMARKS: max-stale without a value accepts a response however stale it is.
+	if requestControl.has("max-stale") && !responseControl.has("must-revalidate") {
+		maxStale, ok := requestControl.duration("max-stale")
+
+		return !ok || age-lifetime <= maxStale
+	}
+
+	return false
+}
+
+// REMARKS: Sends the request and stores the response if it can be; the Cache-Status reports it as forwarded.
+func (c *httpCache) forward(next RoundTripperFunc, request *http.Request, key, reason string, store bool) (*http.Response, error) {
+	requestTime := c.now()
+	resp, err := next(request)
+
+	if err != nil {
+		return resp, err
+	}
+
+	status := "fwd=" + reason
+
+	if store {
+		stored, err := c.storeResponse(request, resp, key, requestTime)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if stored {
+			status += "; stored"
+		}
+	}
+
+	setCacheStatus(resp.Header, status)
+
+	return resp, nil
+}
+
+func (c *httpCache) revalidate(next RoundTripperFunc, request *http.Request, key string, entry *cacheEntry) (*http.Response, error) {
+	conditional := request.Clone(request.Context())
+
+	if etag := entry.Header.Get("ETag"); etag != "" {
+		conditional.Header.Set("If-None-Match", etag)
+	}
+
+	if lastModified := entry.Header.Get("Last-Modified"); lastModified != "" {
+		conditional.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	requestTime := c.now()
+	resp, err := next(conditional)
+
+	if err != nil {
+		return resp, err
+	}
+
+	if resp.StatusCode != http.StatusNotModified {
+		stored, err := c.storeResponse(request, resp, key, requestTime)
+
+		if err != nil {
+			return nil, err
+		}
+
+		status := "fwd=stale; fwd-status=" + strconv.Itoa(resp.StatusCode)
+
+		if stored {
+			status += "; stored"
+		}
+
+		setCacheStatus(resp.Header, status)
+
+		return resp, nil
+	}
+
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+
+	entry = c.refresh(key, entry, resp, requestTime)
+
+	return c.serve(request, entry, c.now(), "fwd=stale; fwd-status=304"), nil
+}
+
+// REMARKS: The revalidation outlives the request that triggered it, so it doesn't use its context.
+func (c *httpCache) revalidateInBackground(next RoundTripperFunc, request *http.Request, key string, entry *cacheEntry) {
+	if _, running := c.revalidations.LoadOrStore(key, true); running {
+		return
+	}
+
+	background := request.Clone(context.Background())
+
+	go func() {
+		defer c.revalidations.Delete(key)
+
+		if resp, err := c.revalidate(next, background, key, entry); err == nil {
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+	}()
+}
+
+// REMARKS: Updates the stored response with the headers of a 304 (Not Modified). Ref: https://www.rfc-editor.org/rfc/rfc9111#section-4.3.4
+func (c *httpCache) refresh(key string, entry *cacheEntry, resp *http.Response, requestTime time.Time) *cacheEntry {
+	refreshed := *entry
+	refreshed.Header = entry.Header.Clone()
+
+	for name, values := range resp.Header {
+		if name == "Content-Length" {
+			continue
+		}
+
+		refreshed.Header[name] = values
+	}
+
+	refreshed.RequestTime = requestTime
+	refreshed.ResponseTime = c.now()
+
+	if data, err := json.Marshal(&refreshed); err == nil {
+		c.store.Set(key, data)
+	}
+
+	return &refreshed
+}
+
+// REMARKS: Reads the body of a storable response (giving the caller a new reader over it) and stores it.
+// Ref: https://www.rfc-editor.org/rfc/rfc9111#section-3
+func (c *httpCache) storeResponse(request *http.Request, resp *http.Response, key string, requestTime time.Time) (bool, error) {
+	control := parseCacheControl(resp.Header.Values("Cache-Control"))
+	varyHeaders := parseVary(resp.Header.Values("Vary"))
+
+	if control.has("no-store") || varyHeaders == nil || parseCacheControl(request.Header.Values("Cache-Control")).has("no-store") {
+		return false, nil
+	}
+
+	// REMARKS: Responses to authorized requests may be personal; they are only stored when the response allows it
+	// explicitly, so that they are never served to other users. Ref: https://www.rfc-editor.org/rfc/rfc9111#section-3.5
+	if c.private(request) && !control.has("public") && !control.has("s-maxage") && !control.has("must-revalidate") {
+		return false, nil
+	}
+
+	_, explicit := control.duration("max-age")
+	explicit = explicit || resp.Header.Get("Expires") != "" || control.has("public")
+
+	if !explicit && !heuristicallyCacheable[resp.StatusCode] {
+		return false, nil
+	}
+
+	if resp.StatusCode == http.StatusPartialContent {
+		return false, nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if err != nil {
+		return false, err
+	}
+
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	entry := &cacheEntry{
+		StatusCode:   resp.StatusCode,
+		Header:       resp.Header.Clone(),
+		Body:         body,
+		RequestTime:  requestTime,
+		ResponseTime: c.now(),
+	}
+
+	if entry.freshnessLifetime() <= 0 && entry.Header.Get("ETag") == "" && entry.Header.Get("Last-Modified") == "" {
+		return false, nil
+	}
+
+	if len(varyHeaders) > 0 {
+		entry.Vary = make(map[string]string, len(varyHeaders))
+
+		for _, name := range varyHeaders {
+			entry.Vary[name] = strings.Join(request.Header.Values(name), ", ")
+		}
+	}
+
+	data, err := json.Marshal(entry)
+
+	if err != nil {
+		return false, nil
+	}
+
+	c.store.Set(key, data)
+
+	return true, nil
+}
+
+// REMARKS: Cookies are treated like credentials. The authorization handlers and the cookie jar add theirs after the
+// cache, which can't see them; authorized reports them.
+func (c *httpCache) private(request *http.Request) bool {
+	return c.authorized || hasAny(request.Header, "Authorization", "Cookie")
+}
+
+func (c *httpCache) serve(request *http.Request, entry *cacheEntry, now time.Time, status string) *http.Response {
+	header := entry.Header.Clone()
+	header.Set("Age", strconv.FormatInt(int64(entry.age(now)/time.Second), 10))
+	setCacheStatus(header, status)
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", entry.StatusCode, http.StatusText(entry.StatusCode)),
+		StatusCode:    entry.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(entry.Body)),
+		ContentLength: int64(len(entry.Body)),
+		Request:       request,
+	}
+}
+
+// REMARKS: Ref: https://www.rfc-editor.org/rfc/rfc9111#section-5.2.1.7
+func (c *httpCache) gatewayTimeout(request *http.Request) *http.Response {
+	header := make(http.Header)
+	setCacheStatus(header, "fwd=miss; detail=only-if-cached")
+
+	return &http.Response{
+		Status:     "504 Gateway Timeout",
+		StatusCode: http.StatusGatewayTimeout,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     header,
+		Body:       http.NoBody,
+		Request:    request,
+	}
+}
+
+// REMARKS: Ref: https://www.rfc-editor.org/rfc/rfc9111#section-4.2.1
+func (e *cacheEntry) freshnessLifetime() time.Duration {
+	control := parseCacheControl(e.Header.Values("Cache-Control"))
+
+	if maxAge, ok := control.duration("max-age"); ok {
+		return maxAge
+	}
+
+	date := e.date()
+
+	if expires := e.Header.Get("Expires"); expires != "" {
+		t, err := http.ParseTime(expires)
+
+		// REMARKS: An invalid Expires (e.g. "0") means already expired.
+		if err != nil {
+			return 0
+		}
+
+		return t.Sub(date)
+	}
+
+	// REMARKS: Heuristic freshness: 10% of the time since the last modification. Ref: section 4.2.2
+	if lastModified, err := http.ParseTime(e.Header.Get("Last-Modified")); err == nil && heuristicallyCacheable[e.StatusCode] {
+		if date.After(lastModified) {
+			return date.Sub(lastModified) / 10
+		}
+	}
+
+	return 0
+}
+
+// REMARKS: Ref: https://www.rfc-editor.org/rfc/rfc9111#section-4.2.3
+func (e *cacheEntry) age(now time.Time) time.Duration {
+	apparentAge := e.ResponseTime.Sub(e.date())
+
+	if apparentAge < 0 {
+		apparentAge = 0
+	}
+
+	ageValue, _ := strconv.ParseInt(e.Header.Get("Age"), 10, 64)
+	correctedAge := time.Duration(ageValue)*time.Second + e.ResponseTime.Sub(e.RequestTime)
+
+	if correctedAge < apparentAge {
+		correctedAge = apparentAge
+	}
+
+	return correctedAge + now.Sub(e.ResponseTime)
+}
+
+func (e *cacheEntry) date() time.Time {
+	if date, err := http.ParseTime(e.Header.Get("Date")); err == nil {
+		return date
+	}
+
+	return e.ResponseTime
+}
+
+// REMARKS: Directive names are lowercase; directives without a value map to "".
+type cacheControl map[string]string
+
+func parseCacheControl(headers []string) cacheControl {
+	control := make(cacheControl)
+
+	for _, header := range headers {
+		for _, directive := range splitAuthItems(header) {
+			if key, value, ok := parseAuthParam(directive); ok {
+				control[key] = value
+			} else {
+				control[strings.ToLower(strings.TrimSpace(directive))] = ""
+			}
+		}
+	}
+
+	return control
+}
+
+func (c cacheControl) has(directive string) bool {
+	_, ok := c[directive]
+
+	return ok
+}
+
+func (c cacheControl) duration(directive string) (time.Duration, bool) {
+	value, ok := c[directive]
+
+	if !ok {
+		return 0, false
+	}
+
+	seconds, err := strconv.ParseInt(value, 10, 64)
+
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+
+	return time.Duration(seconds) * time.Second, true
+}
+
+// REMARKS: Returns the canonical names of the headers listed in Vary, or nil for "Vary: *" (never served from the cache).
+func parseVary(headers []string) []string {
+	names := []string{}
+
+	for _, header := range headers {
+		for _, name := range strings.Split(header, ",") {
+			name = strings.TrimSpace(name)
+
+			if name == "*" {
+				return nil
+			}
+
+			if name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+
+	return names
+}
+
+// REMARKS: The members added by the caches in front of the origin (e.g. a CDN) are kept; the last member is the
+// cache closest to the user. Ref: https://www.rfc-editor.org/rfc/rfc9211#section-2
+func setCacheStatus(header http.Header, status string) {
+	members := append(header.Values("Cache-Status"), cacheStatusName+"; "+status)
+
+	header.Set("Cache-Status", strings.Join(members, ", "))
+}
+
+// REMARKS: Reads the last Cache-Status member added by httpCache. Ref: https://www.rfc-editor.org/rfc/rfc9211
+func parseCacheStatus(header string) CacheStatus {
+	entries := splitAuthItems(header)
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		params := strings.Split(entries[i], ";")
+
+		if strings.TrimSpace(params[0]) != cacheStatusName {
+			continue
+		}
+
+		status := CacheMiss
+
+		for _, param := range params[1:] {
+			switch strings.TrimSpace(param) {
+			case "hit":
+				status = CacheHit
+			case "detail=stale-while-revalidate":
+				return CacheStale
+			case "fwd-status=304":
+				return CacheRevalidated
+			}
+		}
+
+		return status
+	}
+
+	return CacheNone
+}
+
+func hasAny(header http.Header, names ...string) bool {
+	for _, name := range names {
+		if header.Get(name) != "" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,138 @@
+package request
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// REMARKS: In-memory CacheStore that evicts the least recently used entry once it holds maxEntries.
+type memoryCacheStore struct {
+	mutex      sync.Mutex
+	maxEntries int
+	order      *list.List
+	entries    map[string]*list.Element
+}
+
+type memoryCacheEntry struct {
+	key   string
+	value []byte
+}
+
+// REMARKS: CacheStore that keeps each entry in a file of dir named after the SHA-256 of its key. Files are written
+// to a temporary file first and renamed, so that concurrent readers (and processes) never see a partial entry.
+// Errors are ignored: an entry that cannot be read or written is a cache miss.
+type diskCacheStore struct {
+	dir string
+}
+
+func newMemoryCacheStore(maxEntries int) *memoryCacheStore {
+	return &memoryCacheStore{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+func (s *memoryCacheStore) Get(key string) ([]byte, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	element, ok := s.entries[key]
+
+	if !ok {
+		return nil, false
+	}
+
+	s.order.MoveToFront(element)
+
+	return element.Value.(*memoryCacheEntry).value, true
+}
+
+func (s *memoryCacheStore) Set(key string, value []byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if element, ok := s.entries[key]; ok {
+		element.Value.(*memoryCacheEntry).value = value
+		s.order.MoveToFront(element)
+
+		return
+	}
+
+	s.entries[key] = s.order.PushFront(&memoryCacheEntry{key: key, value: value})
+
+	for s.order.Len() > s.maxEntries {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+}
+
+func (s *memoryCacheStore) Delete(key string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if element, ok := s.entries[key]; ok {
+		s.order.Remove(element)
+		delete(s.entries, key)
+	}
+}
+
+func newDiskCacheStore(dir string) (*diskCacheStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return &diskCacheStore{dir: dir}, nil
+}
+
+func (s *diskCacheStore) Get(key string) ([]byte, bool) {
+	data, err := ioutil.ReadFile(s.path(key))
+
+	if err != nil {
+		return nil, false
+	}
+
+	return data, true
+}
+
+func (s *diskCacheStore) Set(key string, value []byte) {
+	file, err := ioutil.TempFile(s.dir, "tmp-")
+
+	if err != nil {
+		return
+	}
+
+	_, err = file.Write(value)
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(file.Name(), s.path(key))
+	}
+
+	if err != nil {
+		os.Remove(file.Name())
+	}
+}
+
+func (s *diskCacheStore) Delete(key string) {
+	os.Remove(s.path(key))
+}
+
+// ***********************************************
+// ********** Private methods/functions **********
+// ***********************************************
+
+func (s *diskCacheStore) path(key string) string {
+	hash := sha256.Sum256([]byte(key))
+
+	return filepath.Join(s.dir, hex.EncodeToString(hash[:]))
+}
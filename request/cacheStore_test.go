@@ -0,0 +1,68 @@
+package request
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryCacheStoreEviction(t *testing.T) {
+	store := NewMemoryCacheStore(2)
+
+	store.Set("a", []byte("1"))
+	store.Set("b", []byte("2"))
+	store.Get("a")
+	store.Set("c", []byte("3"))
+
+	_, ok := store.Get("b")
+
+	assert.False(t, ok, "Should have evicted the least recently used entry")
+
+	value, ok := store.Get("a")
+
+	assert.True(t, ok, "Should have kept the recently used entry")
+	assert.Equal(t, []byte("1"), value, "Should equal value")
+
+	store.Delete("a")
+
+	_, ok = store.Get("a")
+
+	assert.False(t, ok, "Should have deleted the entry")
+}
+
+func TestDiskCacheStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gorequest")
+
+	assert.Nil(t, err, "Should be nil")
+
+	defer os.RemoveAll(dir)
+
+	store, err := NewDiskCacheStore(dir)
+
+	assert.Nil(t, err, "Should be nil")
+
+	store.Set("http://example.com/", []byte("Hello World"))
+
+	// A new store over the same directory sees the entry.
+	store, err = NewDiskCacheStore(dir)
+
+	assert.Nil(t, err, "Should be nil")
+
+	value, ok := store.Get("http://example.com/")
+
+	assert.True(t, ok, "Should have found the entry")
+	assert.Equal(t, []byte("Hello World"), value, "Should equal value")
+
+	store.Delete("http://example.com/")
+
+	_, ok = store.Get("http://example.com/")
+
+	assert.False(t, ok, "Should have deleted the entry")
+
+	files, err := ioutil.ReadDir(dir)
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Empty(t, files, "Should not have left temporary files")
+}
@@ -0,0 +1,407 @@
+package request
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// A cache in front of an origin that answers with the given function, with a clock the test can move.
+type cacheTest struct {
+	cache    *httpCache
+	clock    time.Time
+	requests int32
+}
+
+func newCacheTest(origin func(req *http.Request) *http.Response) (*cacheTest, RoundTripperFunc) {
+	ct := &cacheTest{clock: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	ct.cache = newHttpCache(NewMemoryCacheStore(10))
+	ct.cache.now = func() time.Time { return ct.clock }
+
+	roundTrip := ct.cache.Wrap(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&ct.requests, 1)
+
+		return origin(req), nil
+	})
+
+	return ct, roundTrip
+}
+
+func newCacheResponse(status int, body string, header ...string) *http.Response {
+	resp := &http.Response{StatusCode: status, Header: make(http.Header), Body: ioutil.NopCloser(strings.NewReader(body))}
+
+	for i := 0; i+1 < len(header); i += 2 {
+		resp.Header.Add(header[i], header[i+1])
+	}
+
+	return resp
+}
+
+func cacheGet(t *testing.T, roundTrip RoundTripperFunc, url string, header ...string) (*http.Response, string) {
+	req, err := http.NewRequest("GET", url, nil)
+
+	assert.Nil(t, err, "Should be nil")
+
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+
+	resp, err := roundTrip(req)
+
+	assert.Nil(t, err, "Should be nil")
+
+	body, err := ioutil.ReadAll(resp.Body)
+
+	assert.Nil(t, err, "Should be nil")
+
+	return resp, string(body)
+}
+
+func TestCacheMaxAge(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		resp.Header().Set("Cache-Control", "max-age=60")
+		resp.Write([]byte("Hello World"))
+	}))
+	defer ts.Close()
+
+	client := NewClient().WithCache(NewMemoryCacheStore(0))
+
+	for i, expected := range []CacheStatus{CacheMiss, CacheHit} {
+		req, err := client.NewRequestBuilder().WithUrl(ts.URL).Build()
+
+		assert.Nil(t, err, "Should be nil")
+
+		r, err := req.Do()
+
+		assert.Nil(t, err, "Should be nil")
+		assert.Equal(t, expected, r.CacheStatus(), "Should equal cache status of request %d", i)
+		assert.Equal(t, "Hello World", r.Text(), "Should equal body")
+	}
+
+	// Without a cache.
+	req, err := NewRequestBuilder().WithUrl(ts.URL).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r, err := req.Do()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, CacheNone, r.CacheStatus(), "Should equal CacheNone")
+}
+
+func TestCacheFreshness(t *testing.T) {
+	ct, roundTrip := newCacheTest(func(req *http.Request) *http.Response {
+		return newCacheResponse(200, req.URL.Path, "Cache-Control", "max-age=60")
+	})
+
+	resp, body := cacheGet(t, roundTrip, "http://example.com/a")
+
+	assert.Equal(t, "gorequest; fwd=uri-miss; stored", resp.Header.Get("Cache-Status"), "Should equal cache status")
+	assert.Equal(t, "/a", body, "Should equal body")
+
+	ct.clock = ct.clock.Add(30 * time.Second)
+	resp, body = cacheGet(t, roundTrip, "http://example.com/a")
+
+	assert.Equal(t, "gorequest; hit; ttl=30", resp.Header.Get("Cache-Status"), "Should equal cache status")
+	assert.Equal(t, "30", resp.Header.Get("Age"), "Should equal age")
+	assert.Equal(t, "/a", body, "Should equal body")
+	assert.Equal(t, int32(1), ct.requests, "Should have served the response from the cache")
+
+	cacheGet(t, roundTrip, "http://example.com/a", "Cache-Control", "max-age=10")
+	cacheGet(t, roundTrip, "http://example.com/a", "Cache-Control", "no-cache")
+
+	assert.Equal(t, int32(3), ct.requests, "Should have honored the request directives")
+
+	ct.clock = ct.clock.Add(2 * time.Minute)
+	resp, _ = cacheGet(t, roundTrip, "http://example.com/a")
+
+	assert.Equal(t, int32(4), ct.requests, "Should have fetched the expired response")
+	assert.Equal(t, CacheMiss, parseCacheStatus(resp.Header.Get("Cache-Status")), "Should equal CacheMiss")
+}
+
+func TestCacheRevalidation(t *testing.T) {
+	ct, roundTrip := newCacheTest(func(req *http.Request) *http.Response {
+		if req.Header.Get("If-None-Match") == `"v1"` {
+			return newCacheResponse(304, "", "ETag", `"v1"`, "X-Version", "2")
+		}
+
+		return newCacheResponse(200, "content", "ETag", `"v1"`, "Cache-Control", "no-cache", "X-Version", "1")
+	})
+
+	cacheGet(t, roundTrip, "http://example.com/")
+	resp, body := cacheGet(t, roundTrip, "http://example.com/")
+
+	assert.Equal(t, int32(2), ct.requests, "Should have revalidated the response")
+	assert.Equal(t, CacheRevalidated, parseCacheStatus(resp.Header.Get("Cache-Status")), "Should equal CacheRevalidated")
+	assert.Equal(t, 200, resp.StatusCode, "Should equal the status of the stored response")
+	assert.Equal(t, "content", body, "Should equal the stored body")
+	assert.Equal(t, "2", resp.Header.Get("X-Version"), "Should have updated the headers from the 304")
+
+	// Conditional requests made by the caller are passed through.
+	resp, _ = cacheGet(t, roundTrip, "http://example.com/", "If-None-Match", `"v1"`)
+
+	assert.Equal(t, 304, resp.StatusCode, "Should equal HTTP Status 304 (Not Modified)")
+}
+
+func TestCacheVary(t *testing.T) {
+	ct, roundTrip := newCacheTest(func(req *http.Request) *http.Response {
+		return newCacheResponse(200, req.Header.Get("Accept-Language"), "Cache-Control", "max-age=60", "Vary", "accept-language")
+	})
+
+	cacheGet(t, roundTrip, "http://example.com/", "Accept-Language", "en")
+	_, body := cacheGet(t, roundTrip, "http://example.com/", "Accept-Language", "en")
+
+	assert.Equal(t, "en", body, "Should equal body")
+	assert.Equal(t, int32(1), ct.requests, "Should have served the matching variant")
+
+	_, body = cacheGet(t, roundTrip, "http://example.com/", "Accept-Language", "fr")
+
+	assert.Equal(t, "fr", body, "Should equal body")
+	assert.Equal(t, int32(2), ct.requests, "Should not have served another variant")
+}
+
+func TestCacheStaleWhileRevalidate(t *testing.T) {
+	revalidated := make(chan bool, 1)
+	version := int32(0)
+
+	ct, roundTrip := newCacheTest(func(req *http.Request) *http.Response {
+		v := atomic.AddInt32(&version, 1)
+
+		if v > 1 {
+			defer func() { revalidated <- true }()
+		}
+
+		return newCacheResponse(200, string(rune('0'+v)), "Cache-Control", "max-age=10, stale-while-revalidate=60")
+	})
+
+	cacheGet(t, roundTrip, "http://example.com/")
+
+	ct.clock = ct.clock.Add(30 * time.Second)
+	resp, body := cacheGet(t, roundTrip, "http://example.com/")
+
+	assert.Equal(t, CacheStale, parseCacheStatus(resp.Header.Get("Cache-Status")), "Should equal CacheStale")
+	assert.Equal(t, "1", body, "Should have served the stale response")
+
+	select {
+	case <-revalidated:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Should have revalidated the response in the background")
+	}
+
+	// Wait for the background revalidation to store the response.
+	for i := 0; i < 100; i++ {
+		if _, running := ct.cache.revalidations.Load("http://example.com/"); !running {
+			break
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	resp, body = cacheGet(t, roundTrip, "http://example.com/")
+
+	assert.Equal(t, CacheHit, parseCacheStatus(resp.Header.Get("Cache-Status")), "Should equal CacheHit")
+	assert.Equal(t, "2", body, "Should have served the revalidated response")
+
+	// Past the stale-while-revalidate window, the request waits for the origin.
+	ct.clock = ct.clock.Add(2 * time.Minute)
+	_, body = cacheGet(t, roundTrip, "http://example.com/")
+
+	assert.Equal(t, "3", body, "Should have fetched a new response")
+}
+
+func TestCacheNotStored(t *testing.T) {
+	ct, roundTrip := newCacheTest(func(req *http.Request) *http.Response {
+		switch req.URL.Path {
+		case "/no-store":
+			return newCacheResponse(200, "", "Cache-Control", "no-store, max-age=60")
+		case "/vary":
+			return newCacheResponse(200, "", "Cache-Control", "max-age=60", "Vary", "*")
+		case "/error":
+			return newCacheResponse(500, "")
+		}
+
+		return newCacheResponse(200, "")
+	})
+
+	for _, path := range []string{"/no-store", "/vary", "/error", "/no-validator"} {
+		resp, _ := cacheGet(t, roundTrip, "http://example.com"+path)
+
+		assert.Equal(t, "gorequest; fwd=uri-miss", resp.Header.Get("Cache-Status"), "Should not have stored "+path)
+	}
+
+	resp, _ := cacheGet(t, roundTrip, "http://example.com/other", "Cache-Control", "only-if-cached")
+
+	assert.Equal(t, http.StatusGatewayTimeout, resp.StatusCode, "Should equal HTTP Status 504 (Gateway Timeout)")
+	assert.Equal(t, int32(4), ct.requests, "Should not have sent the only-if-cached request")
+}
+
+func TestCacheHeuristicFreshness(t *testing.T) {
+	ct, roundTrip := newCacheTest(func(req *http.Request) *http.Response {
+		return newCacheResponse(200, "", "Date", "Mon, 01 Jan 2024 00:00:00 GMT", "Last-Modified", "Fri, 22 Dec 2023 00:00:00 GMT")
+	})
+
+	cacheGet(t, roundTrip, "http://example.com/")
+
+	ct.clock = ct.clock.Add(20 * time.Hour)
+	resp, _ := cacheGet(t, roundTrip, "http://example.com/")
+
+	assert.Equal(t, "gorequest; hit; ttl=14400", resp.Header.Get("Cache-Status"), "Should be fresh for 10% of the time since the last modification")
+	assert.Equal(t, int32(1), ct.requests, "Should have served the response from the cache")
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	ct, roundTrip := newCacheTest(func(req *http.Request) *http.Response {
+		return newCacheResponse(200, "", "Cache-Control", "max-age=60")
+	})
+
+	cacheGet(t, roundTrip, "http://example.com/items")
+
+	req, err := http.NewRequest("POST", "http://example.com/items", strings.NewReader("item"))
+
+	assert.Nil(t, err, "Should be nil")
+
+	_, err = roundTrip(req)
+
+	assert.Nil(t, err, "Should be nil")
+
+	cacheGet(t, roundTrip, "http://example.com/items")
+
+	assert.Equal(t, int32(3), ct.requests, "Should have invalidated the stored response")
+}
+
+// An authorization method that adds its credentials after the cache, like OAuth2, Digest or AWS SigV4.
+type cacheTestAuth struct {
+	user string
+}
+
+func (a *cacheTestAuth) Configure(request *http.Request) {
+}
+
+func (a *cacheTestAuth) Wrap(next RoundTripperFunc) RoundTripperFunc {
+	return func(request *http.Request) (*http.Response, error) {
+		request.Header.Set("X-User", a.user)
+
+		return next(request)
+	}
+}
+
+func TestCacheAuthorizedRequests(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		user := req.Header.Get("X-User")
+
+		if auth := req.Header.Get("Authorization"); auth != "" {
+			user = strings.TrimPrefix(auth, "Bearer ")
+		}
+
+		resp.Header().Set("Cache-Control", req.URL.Query().Get("cache-control"))
+		resp.Write([]byte("secret-of-" + user))
+	}))
+	defer ts.Close()
+
+	client := NewClient().WithCache(NewMemoryCacheStore(0))
+
+	get := func(path string, builder RequestBuilder) Response {
+		req, err := builder.WithUrl(ts.URL + path).Build()
+
+		assert.Nil(t, err, "Should be nil")
+
+		r, err := req.Do()
+
+		assert.Nil(t, err, "Should be nil")
+
+		return r
+	}
+
+	for _, path := range []string{"/bearer?cache-control=max-age%3D60", "/cookie?cache-control=max-age%3D60"} {
+		alice := get(path, client.NewRequestBuilder().WithBearerAuth("alice").WithCookie(&http.Cookie{Name: "session", Value: "alice"}))
+		bob := get(path, client.NewRequestBuilder().WithBearerAuth("bob"))
+
+		assert.Equal(t, "secret-of-alice", alice.Text(), "Should equal the body of alice")
+		assert.Equal(t, "secret-of-bob", bob.Text(), "Should not have served the response of alice to bob")
+		assert.Equal(t, CacheMiss, bob.CacheStatus(), "Should equal CacheMiss")
+	}
+
+	alice := get("/handler?cache-control=max-age%3D60", client.NewRequestBuilder().WithAuth(&cacheTestAuth{user: "alice"}))
+	bob := get("/handler?cache-control=max-age%3D60", client.NewRequestBuilder().WithAuth(&cacheTestAuth{user: "bob"}))
+
+	assert.Equal(t, "secret-of-alice", alice.Text(), "Should equal the body of alice")
+	assert.Equal(t, "secret-of-bob", bob.Text(), "Should not have stored the response to a request authorized after the cache")
+
+	// Responses that say they can be shared are.
+	alice = get("/public?cache-control=public%2C+max-age%3D60", client.NewRequestBuilder().WithBearerAuth("alice"))
+	bob = get("/public?cache-control=public%2C+max-age%3D60", client.NewRequestBuilder().WithBearerAuth("bob"))
+
+	assert.Equal(t, "secret-of-alice", bob.Text(), "Should have served the public response")
+	assert.Equal(t, CacheHit, bob.CacheStatus(), "Should equal CacheHit")
+}
+
+func TestCacheStatusOfUpstreamCaches(t *testing.T) {
+	ct, roundTrip := newCacheTest(func(req *http.Request) *http.Response {
+		return newCacheResponse(200, "Hello World", "Cache-Control", "max-age=60", "Cache-Status", "OriginCache; hit; ttl=60", "Cache-Status", "cdn; fwd=uri-miss")
+	})
+
+	resp, _ := cacheGet(t, roundTrip, "http://example.com/a")
+
+	assert.Equal(t, "OriginCache; hit; ttl=60, cdn; fwd=uri-miss, gorequest; fwd=uri-miss; stored", resp.Header.Get("Cache-Status"), "Should have kept the members of the upstream caches")
+
+	ct.clock = ct.clock.Add(30 * time.Second)
+	resp, _ = cacheGet(t, roundTrip, "http://example.com/a")
+
+	assert.Equal(t, "OriginCache; hit; ttl=60, cdn; fwd=uri-miss, gorequest; hit; ttl=30", resp.Header.Get("Cache-Status"), "Should have kept the members of the upstream caches")
+
+	// Another gorequest cache in front of the origin.
+	assert.Equal(t, CacheMiss, parseCacheStatus("gorequest; hit; ttl=10, gorequest; fwd=uri-miss"), "Should equal the status of the last member")
+}
+
+func TestCacheRevalidationsPerCache(t *testing.T) {
+	release := make(chan bool)
+	first := int32(0)
+
+	newCache := func(revalidated chan bool) (*cacheTest, RoundTripperFunc) {
+		version := int32(0)
+
+		return newCacheTest(func(req *http.Request) *http.Response {
+			if atomic.AddInt32(&version, 1) > 1 {
+				// The first cache to revalidate waits until the end of the test.
+				if atomic.CompareAndSwapInt32(&first, 0, 1) {
+					<-release
+				}
+
+				revalidated <- true
+			}
+
+			return newCacheResponse(200, "Hello World", "Cache-Control", "max-age=10, stale-while-revalidate=60")
+		})
+	}
+
+	revalidated := make(chan bool, 2)
+	ct1, roundTrip1 := newCache(revalidated)
+	ct2, roundTrip2 := newCache(revalidated)
+
+	defer close(release)
+
+	for _, roundTrip := range []RoundTripperFunc{roundTrip1, roundTrip2} {
+		cacheGet(t, roundTrip, "http://example.com/")
+	}
+
+	ct1.clock = ct1.clock.Add(30 * time.Second)
+	ct2.clock = ct2.clock.Add(30 * time.Second)
+
+	for _, roundTrip := range []RoundTripperFunc{roundTrip1, roundTrip2} {
+		resp, _ := cacheGet(t, roundTrip, "http://example.com/")
+
+		assert.Equal(t, CacheStale, parseCacheStatus(resp.Header.Get("Cache-Status")), "Should equal CacheStale")
+	}
+
+	select {
+	case <-revalidated:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Should have revalidated the response of the other cache")
+	}
+}
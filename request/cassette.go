@@ -0,0 +1,491 @@
+package request
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// REMARKS: Records the interactions of a transport in a cassette file (YAML or JSON) and replays them, so that tests against a
+// remote service can run offline. It sits in front of the transport (see WithTransport), so every exchange is an
+// interaction of its own: each redirect and each attempt (retries, authorization challenges). A recorded interaction
+// is replayed once, in the order of the file; when all of the matching interactions have been replayed, the last one
+// is replayed again. The redacted headers are masked like the logs (see logPolicy), before the sanitizers run.
+type cassette struct {
+	mutex           sync.Mutex
+	path            string
+	mode            CassetteMode
+	transport       http.RoundTripper
+	redactedHeaders map[string]bool
+	matchers        []CassetteMatcher
+	requestHooks    []func(request *CassetteRequest)
+	responseHooks   []func(response *CassetteResponse)
+	interactions    []*cassetteInteraction
+	changed         bool
+}
+
+type cassetteInteraction struct {
+	request  *CassetteRequest
+	response *CassetteResponse
+	replayed bool
+}
+
+// REMARKS: The same fields in both formats; YAML files are the ones named *.yaml or *.yml.
+type cassetteFile struct {
+	Version      int                 `json:"version" yaml:"version"`
+	Interactions []cassetteEntryFile `json:"interactions" yaml:"interactions"`
+}
+
+type cassetteEntryFile struct {
+	Request  cassetteRequestFile  `json:"request" yaml:"request"`
+	Response cassetteResponseFile `json:"response" yaml:"response"`
+}
+
+// REMARKS: Binary bodies are base64 encoded, which bodyEncoding records.
+type cassetteRequestFile struct {
+	Method       string      `json:"method" yaml:"method"`
+	Url          string      `json:"url" yaml:"url"`
+	Header       http.Header `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body         string      `json:"body,omitempty" yaml:"body,omitempty"`
+	BodyEncoding string      `json:"bodyEncoding,omitempty" yaml:"bodyEncoding,omitempty"`
+}
+
+type cassetteResponseFile struct {
+	StatusCode   int         `json:"status" yaml:"status"`
+	Header       http.Header `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body         string      `json:"body,omitempty" yaml:"body,omitempty"`
+	BodyEncoding string      `json:"bodyEncoding,omitempty" yaml:"bodyEncoding,omitempty"`
+}
+
+const cassetteVersion = 1
+
+// REMARKS: A missing file is an empty cassette, created by Save.
+func newCassette(path string) (*cassette, error) {
+	c := &cassette{
+		path:            path,
+		mode:            CassetteRecordMissing,
+		transport:       defaultHttpClient.Transport,
+		redactedHeaders: make(map[string]bool),
+		matchers:        defaultCassetteMatchers,
+	}
+
+	c.WithRedactedHeaders(defaultRedactedHeaders...)
+
+	data, err := ioutil.ReadFile(path)
+
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.unmarshal(data); err != nil {
+		return nil, fmt.Errorf("Invalid cassette %q. %v", path, err)
+	}
+
+	return c, nil
+}
+
+// REMARKS: CassetteRecord starts from an empty cassette: every interaction is recorded again.
+func (c *cassette) WithMode(mode CassetteMode) Cassette {
+	c.mode = mode
+
+	if mode == CassetteRecord {
+		c.interactions = nil
+		c.changed = true
+	}
+
+	return c
+}
+
+// REMARKS: The transport used to send the requests that are not replayed. A nil transport is the default one (see
+// NewTransport).
+func (c *cassette) WithTransport(transport http.RoundTripper) Cassette {
+	if transport == nil {
+		transport = defaultHttpClient.Transport
+	}
+
+	c.transport = transport
+
+	return c
+}
+
+// REMARKS: Adds to the default headers (Authorization, Proxy-Authorization, Cookie and Set-Cookie), which are
+// redacted in the requests and the responses that are recorded. The requests being sent are redacted too before they
+// are matched, so that matching ignores the redacted values; the scheme of an authorization and the names of cookies
+// are kept, e.g. "Bearer [REDACTED]".
+func (c *cassette) WithRedactedHeaders(names ...string) Cassette {
+	for _, name := range names {
+		c.redactedHeaders[http.CanonicalHeaderKey(name)] = true
+	}
+
+	return c
+}
+
+// REMARKS: Replaces the matchers; a request matches a recorded interaction when all of them match. Defaults to
+// MatchMethod and MatchUrl.
+func (c *cassette) WithMatchers(matchers ...CassetteMatcher) Cassette {
+	c.matchers = matchers
+
+	return c
+}
+
+// REMARKS: Sanitizers run in the order they were added, on the requests before they are recorded (e.g. to redact
+// credentials), and on the requests being sent before they are matched, so that both look alike.
+func (c *cassette) WithRequestSanitizer(sanitizer func(request *CassetteRequest)) Cassette {
+	c.requestHooks = append(c.requestHooks, sanitizer)
+
+	return c
+}
+
+// REMARKS: Sanitizers run on the responses before they are recorded; the response returned while recording is the
+// one received.
+func (c *cassette) WithResponseSanitizer(sanitizer func(response *CassetteResponse)) Cassette {
+	c.responseHooks = append(c.responseHooks, sanitizer)
+
+	return c
+}
+
+func (c *cassette) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return len(c.interactions)
+}
+
+// REMARKS: Does nothing when no interaction was recorded, so that replaying never writes to the file. Like cookies,
+// the file is only readable by its owner.
+func (c *cassette) Save() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if !c.changed {
+		return nil
+	}
+
+	data, err := c.marshal()
+
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(c.path, data, 0600); err != nil {
+		return err
+	}
+
+	c.changed = false
+
+	return nil
+}
+
+func (c *cassette) RoundTrip(request *http.Request) (*http.Response, error) {
+	if c.mode == CassettePassthrough {
+		return c.transport.RoundTrip(request)
+	}
+
+	body, err := readRequestBody(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := c.sanitizeRequest(newCassetteRequest(request, body))
+
+	if c.mode != CassetteRecord {
+		if response := c.replay(recorded); response != nil {
+			return response.httpResponse(request), nil
+		}
+
+		if c.mode == CassetteReplay {
+			return nil, fmt.Errorf("No interaction recorded in %q for %s %s.", c.path, recorded.Method, recorded.Url)
+		}
+	}
+
+	outgoing := request.Clone(request.Context())
+	outgoing.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	if body == nil {
+		outgoing.Body = nil
+	}
+
+	resp, err := c.transport.RoundTrip(outgoing)
+
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+
+	c.record(recorded, c.sanitizeResponse(&CassetteResponse{StatusCode: resp.StatusCode, Header: resp.Header.Clone(), Body: data}))
+
+	return resp, nil
+}
+
+// ***********************************************
+// ********** Private methods/functions **********
+// ***********************************************
+
+// REMARKS: A RoundTripper must close the body of the request, even on errors.
+func readRequestBody(request *http.Request) ([]byte, error) {
+	if request.Body == nil || request.Body == http.NoBody {
+		return nil, nil
+	}
+
+	defer request.Body.Close()
+
+	return ioutil.ReadAll(request.Body)
+}
+
+func matchCassetteMethod(request, recorded *CassetteRequest) bool {
+	return strings.EqualFold(request.Method, recorded.Method)
+}
+
+func matchCassetteUrl(request, recorded *CassetteRequest) bool {
+	u1, err1 := url.Parse(request.Url)
+	u2, err2 := url.Parse(recorded.Url)
+
+	if err1 != nil || err2 != nil {
+		return request.Url == recorded.Url
+	}
+
+	return u1.Scheme == u2.Scheme && u1.Host == u2.Host && u1.EscapedPath() == u2.EscapedPath() && u1.Query().Encode() == u2.Query().Encode()
+}
+
+func matchCassetteBody(request, recorded *CassetteRequest) bool {
+	return bytes.Equal(request.Body, recorded.Body)
+}
+
+func matchCassetteHeaders(names []string) CassetteMatcher {
+	return func(request, recorded *CassetteRequest) bool {
+		for _, name := range names {
+			if strings.Join(request.Header.Values(name), ", ") != strings.Join(recorded.Header.Values(name), ", ") {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// REMARKS: The URL is recorded as sent, without credentials: http.Client sends them in the Authorization header.
+func newCassetteRequest(request *http.Request, body []byte) *CassetteRequest {
+	u := *request.URL
+	u.User = nil
+
+	return &CassetteRequest{
+		Method: request.Method,
+		Url:    u.String(),
+		Header: request.Header.Clone(),
+		Body:   append([]byte(nil), body...),
+	}
+}
+
+func (c *cassette) sanitizeRequest(request *CassetteRequest) *CassetteRequest {
+	c.redactHeader(request.Header)
+
+	for _, hook := range c.requestHooks {
+		hook(request)
+	}
+
+	return request
+}
+
+func (c *cassette) sanitizeResponse(response *CassetteResponse) *CassetteResponse {
+	c.redactHeader(response.Header)
+
+	for _, hook := range c.responseHooks {
+		hook(response)
+	}
+
+	return response
+}
+
+func (c *cassette) redactHeader(header http.Header) {
+	for name, values := range header {
+		if !c.redactedHeaders[name] {
+			continue
+		}
+
+		for i, value := range values {
+			values[i] = redactHeaderValue(name, value)
+		}
+	}
+}
+
+func (c *cassette) replay(request *CassetteRequest) *CassetteResponse {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var last *cassetteInteraction
+
+	for _, interaction := range c.interactions {
+		if !c.matches(request, interaction.request) {
+			continue
+		}
+
+		if !interaction.replayed {
+			interaction.replayed = true
+
+			return interaction.response
+		}
+
+		last = interaction
+	}
+
+	if last == nil {
+		return nil
+	}
+
+	return last.response
+}
+
+func (c *cassette) matches(request, recorded *CassetteRequest) bool {
+	for _, matcher := range c.matchers {
+		if !matcher(request, recorded) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// REMARKS: A recorded interaction has been replayed already, so that the next matching request gets the next one.
+func (c *cassette) record(request *CassetteRequest, response *CassetteResponse) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.interactions = append(c.interactions, &cassetteInteraction{request: request, response: response, replayed: true})
+	c.changed = true
+}
+
+func (r *CassetteResponse) httpResponse(request *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        r.Header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       request,
+	}
+}
+
+func (c *cassette) marshal() ([]byte, error) {
+	file := cassetteFile{Version: cassetteVersion, Interactions: []cassetteEntryFile{}}
+
+	for _, interaction := range c.interactions {
+		request, response := interaction.request, interaction.response
+		entry := cassetteEntryFile{
+			Request:  cassetteRequestFile{Method: request.Method, Url: request.Url, Header: request.Header},
+			Response: cassetteResponseFile{StatusCode: response.StatusCode, Header: response.Header},
+		}
+
+		entry.Request.Body, entry.Request.BodyEncoding = encodeCassetteBody(request.Body)
+		entry.Response.Body, entry.Response.BodyEncoding = encodeCassetteBody(response.Body)
+
+		file.Interactions = append(file.Interactions, entry)
+	}
+
+	if c.isYaml() {
+		return yaml.Marshal(file)
+	}
+
+	return json.MarshalIndent(file, "", "  ")
+}
+
+func (c *cassette) unmarshal(data []byte) error {
+	var file cassetteFile
+
+	if c.isYaml() {
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return fmt.Errorf("Invalid YAML: %v.", err)
+		}
+	} else if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("Invalid JSON: %v.", err)
+	}
+
+	if file.Version != cassetteVersion {
+		return fmt.Errorf("Unsupported cassette version %d.", file.Version)
+	}
+
+	for _, entry := range file.Interactions {
+		requestBody, err := decodeCassetteBody(entry.Request.Body, entry.Request.BodyEncoding)
+
+		if err != nil {
+			return err
+		}
+
+		responseBody, err := decodeCassetteBody(entry.Response.Body, entry.Response.BodyEncoding)
+
+		if err != nil {
+			return err
+		}
+
+		c.interactions = append(c.interactions, &cassetteInteraction{
+			request:  &CassetteRequest{Method: entry.Request.Method, Url: entry.Request.Url, Header: entry.Request.Header, Body: requestBody},
+			response: &CassetteResponse{StatusCode: entry.Response.StatusCode, Header: entry.Response.Header, Body: responseBody},
+		})
+	}
+
+	return nil
+}
+
+func (c *cassette) isYaml() bool {
+	switch strings.ToLower(filepath.Ext(c.path)) {
+	case ".yaml", ".yml":
+		return true
+	default:
+		return false
+	}
+}
+
+func encodeCassetteBody(data []byte) (string, string) {
+	if !utf8.Valid(data) {
+		return base64.StdEncoding.EncodeToString(data), "base64"
+	}
+
+	return string(data), ""
+}
+
+func decodeCassetteBody(text string, encoding string) ([]byte, error) {
+	switch encoding {
+	case "":
+		if text == "" {
+			return nil, nil
+		}
+
+		return []byte(text), nil
+	case "base64":
+		data, err := base64.StdEncoding.DecodeString(text)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid base64 body: %v.", err)
+		}
+
+		return data, nil
+	default:
+		return nil, fmt.Errorf("Unsupported body encoding %q.", encoding)
+	}
+}
@@ -0,0 +1,418 @@
+package request
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newCassetteServer(hits *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		n := atomic.AddInt32(hits, 1)
+		body, _ := ioutil.ReadAll(req.Body)
+
+		if req.URL.Path == "/binary" {
+			resp.Header().Set("Content-Type", "application/octet-stream")
+			resp.Write([]byte{0xff, 0xfe, byte(n)})
+			return
+		}
+
+		resp.Header().Set("Content-Type", "text/plain")
+		resp.Header().Set("X-Token", "secret")
+		resp.WriteHeader(http.StatusCreated)
+		resp.Write([]byte(req.Method + " " + req.URL.RawQuery + " " + string(body) + " " + req.Header.Get("Authorization") + " #" + strconv.Itoa(int(n))))
+	}))
+}
+
+func doCassetteRequest(t *testing.T, cassette Cassette, builder RequestBuilder) (Response, error) {
+	req, err := builder.WithTransport(cassette).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	return req.Do()
+}
+
+func TestCassetteRecordAndReplay(t *testing.T) {
+	var hits int32
+
+	ts := newCassetteServer(&hits)
+
+	dir, err := ioutil.TempDir("", "gorequest")
+
+	assert.Nil(t, err, "Should be nil")
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "cassette.json")
+	builders := func() []RequestBuilder {
+		return []RequestBuilder{
+			NewRequestBuilder().WithUrl(ts.URL + "/items?b=2&a=1"),
+			NewRequestBuilder().WithMethod("POST").WithUrl(ts.URL + "/items").WithJsonBody(`{"name":"john"}`),
+			NewRequestBuilder().WithUrl(ts.URL + "/binary"),
+		}
+	}
+
+	cassette, err := NewCassette(path)
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, 0, cassette.Len(), "Should be empty")
+
+	var expected [][]byte
+
+	for _, builder := range builders() {
+		r, err := doCassetteRequest(t, cassette, builder)
+
+		assert.Nil(t, err, "Should be nil")
+
+		expected = append(expected, r.Body())
+	}
+
+	assert.Equal(t, int32(3), hits, "Should have sent the requests")
+	assert.Equal(t, 3, cassette.Len(), "Should have recorded the interactions")
+	assert.Nil(t, cassette.Save(), "Should be nil")
+
+	// Replayed offline, with the query parameters in another order.
+	ts.Close()
+
+	cassette, err = NewCassette(path)
+
+	assert.Nil(t, err, "Should be nil")
+
+	cassette.WithMode(CassetteReplay)
+
+	replayed := builders()
+	replayed[0] = NewRequestBuilder().WithUrl(ts.URL + "/items?a=1&b=2")
+
+	for i, builder := range replayed {
+		r, err := doCassetteRequest(t, cassette, builder)
+
+		assert.Nil(t, err, "Should be nil")
+		assert.Equal(t, expected[i], r.Body(), "Should have replayed the response")
+	}
+
+	r, err := doCassetteRequest(t, cassette, replayed[0])
+
+	assert.Nil(t, err, "Should have replayed the last interaction again")
+	assert.Equal(t, http.StatusCreated, r.StatusCode(), "Should equal HTTP Status 201 (Created)")
+	assert.Equal(t, "text/plain", r.Header("Content-Type"), "Should have replayed the headers")
+	assert.Equal(t, []byte{0xff, 0xfe, 3}, expected[2], "Should have recorded the binary body")
+
+	_, err = doCassetteRequest(t, cassette, NewRequestBuilder().WithMethod("DELETE").WithUrl(ts.URL+"/items"))
+
+	var transportError *TransportError
+
+	assert.True(t, errors.As(err, &transportError), "Should not have replayed a request that was not recorded")
+
+	info, err := os.Stat(path)
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Nil(t, cassette.Save(), "Should be nil")
+
+	after, err := os.Stat(path)
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, info.ModTime(), after.ModTime(), "Should not have written the file")
+}
+
+func TestCassetteModes(t *testing.T) {
+	var hits int32
+
+	ts := newCassetteServer(&hits)
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "gorequest")
+
+	assert.Nil(t, err, "Should be nil")
+
+	defer os.RemoveAll(dir)
+
+	cassette, err := NewCassette(filepath.Join(dir, "cassette.json"))
+
+	assert.Nil(t, err, "Should be nil")
+
+	builder := NewRequestBuilder().WithUrl(ts.URL)
+
+	// Recorded when missing, then replayed.
+	for i := 0; i < 2; i++ {
+		r, err := doCassetteRequest(t, cassette, builder)
+
+		assert.Nil(t, err, "Should be nil")
+		assert.Equal(t, "GET    #1", r.Text(), "Should equal the first response")
+	}
+
+	cassette.WithMode(CassettePassthrough)
+
+	r, err := doCassetteRequest(t, cassette, builder)
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, "GET    #2", r.Text(), "Should have sent the request")
+	assert.Equal(t, 1, cassette.Len(), "Should not have recorded the request")
+
+	cassette.WithMode(CassetteRecord)
+
+	r, err = doCassetteRequest(t, cassette, builder)
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, "GET    #3", r.Text(), "Should have sent the request")
+	assert.Equal(t, 1, cassette.Len(), "Should have recorded the request again")
+
+	cassette.WithMode(CassetteReplay)
+
+	r, err = doCassetteRequest(t, cassette, builder)
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, "GET    #3", r.Text(), "Should have replayed the new recording")
+	assert.Equal(t, int32(3), hits, "Should not have sent the request")
+}
+
+func TestCassetteMatchers(t *testing.T) {
+	var hits int32
+
+	ts := newCassetteServer(&hits)
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "gorequest")
+
+	assert.Nil(t, err, "Should be nil")
+
+	defer os.RemoveAll(dir)
+
+	cassette, err := NewCassette(filepath.Join(dir, "cassette.json"))
+
+	assert.Nil(t, err, "Should be nil")
+
+	cassette.WithMatchers(MatchMethod, MatchUrl, MatchBody, MatchHeaders("X-Tenant"))
+
+	send := func(body, tenant string) string {
+		r, err := doCassetteRequest(t, cassette, NewRequestBuilder().WithMethod("POST").WithUrl(ts.URL).WithTextBody(body).WithHeader("X-Tenant", tenant))
+
+		assert.Nil(t, err, "Should be nil")
+
+		return r.Text()
+	}
+
+	assert.Equal(t, "POST  a  #1", send("a", "1"), "Should have recorded the request")
+	assert.Equal(t, "POST  b  #2", send("b", "1"), "Should have matched the body")
+	assert.Equal(t, "POST  a  #3", send("a", "2"), "Should have matched the header")
+	assert.Equal(t, "POST  a  #1", send("a", "1"), "Should have replayed the request")
+	assert.Equal(t, int32(3), hits, "Should equal the number of requests sent")
+
+	assert.Nil(t, cassette.Save(), "Should be nil")
+
+	// Without the body and header matchers, the interactions are replayed in order, then the last one again.
+	cassette, err = NewCassette(filepath.Join(dir, "cassette.json"))
+
+	assert.Nil(t, err, "Should be nil")
+
+	cassette.WithMode(CassetteReplay).WithMatchers(MatchMethod, MatchUrl)
+
+	for _, expected := range []string{"POST  a  #1", "POST  b  #2", "POST  a  #3", "POST  a  #3"} {
+		assert.Equal(t, expected, send("c", "3"), "Should have replayed the interactions in order")
+	}
+
+	assert.Equal(t, int32(3), hits, "Should not have sent the requests")
+}
+
+func TestCassetteSanitizers(t *testing.T) {
+	var hits int32
+
+	ts := newCassetteServer(&hits)
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "gorequest")
+
+	assert.Nil(t, err, "Should be nil")
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "cassette.json")
+	load := func() Cassette {
+		cassette, err := NewCassette(path)
+
+		assert.Nil(t, err, "Should be nil")
+
+		return cassette.
+			WithMatchers(MatchMethod, MatchUrl, MatchHeaders("Authorization")).
+			WithRequestSanitizer(func(request *CassetteRequest) {
+				if request.Header.Get("Authorization") != "" {
+					request.Header.Set("Authorization", "[REDACTED]")
+				}
+			}).
+			WithResponseSanitizer(func(response *CassetteResponse) {
+				response.Header.Del("X-Token")
+				response.Body = bytes.Replace(response.Body, []byte("hunter2"), []byte("[REDACTED]"), -1)
+			})
+	}
+
+	cassette := load()
+
+	r, err := doCassetteRequest(t, cassette, NewRequestBuilder().WithUrl(ts.URL).WithBearerAuth("hunter2"))
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, "GET   Bearer hunter2 #1", r.Text(), "Should have returned the response received")
+	assert.Equal(t, "secret", r.Header("X-Token"), "Should have returned the headers received")
+	assert.Nil(t, cassette.Save(), "Should be nil")
+
+	data, err := ioutil.ReadFile(path)
+
+	assert.Nil(t, err, "Should be nil")
+	assert.False(t, strings.Contains(string(data), "hunter2"), "Should have sanitized the cassette")
+	assert.False(t, strings.Contains(string(data), "secret"), "Should have sanitized the cassette")
+
+	cassette = load().WithMode(CassetteReplay)
+
+	r, err = doCassetteRequest(t, cassette, NewRequestBuilder().WithUrl(ts.URL).WithBearerAuth("another token"))
+
+	assert.Nil(t, err, "Should have matched the sanitized request")
+	assert.Equal(t, "GET   Bearer [REDACTED] #1", r.Text(), "Should have replayed the sanitized response")
+	assert.Equal(t, "", r.Header("X-Token"), "Should have replayed the sanitized headers")
+
+	_, err = doCassetteRequest(t, cassette, NewRequestBuilder().WithUrl(ts.URL))
+
+	assert.NotNil(t, err, "Should not have matched a request without authorization")
+}
+
+func TestCassetteRedactedHeaders(t *testing.T) {
+	var hits int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		resp.Header().Set("Set-Cookie", "session=s3cr3t; Path=/")
+		resp.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "gorequest")
+
+	assert.Nil(t, err, "Should be nil")
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "cassette.json")
+	load := func() Cassette {
+		cassette, err := NewCassette(path)
+
+		assert.Nil(t, err, "Should be nil")
+
+		return cassette.
+			WithRedactedHeaders("X-Api-Key").
+			WithMatchers(MatchMethod, MatchUrl, MatchHeaders("Authorization", "Cookie", "X-Api-Key"))
+	}
+	builder := func(password, session, key string) RequestBuilder {
+		return NewRequestBuilder().
+			WithUrl(ts.URL).
+			WithBasicAuth("user", password).
+			WithHeader("Cookie", "session="+session).
+			WithHeader("X-Api-Key", key)
+	}
+
+	cassette := load()
+
+	r, err := doCassetteRequest(t, cassette, builder("hunter2", "s3cr3t", "k3y"))
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, "session=s3cr3t; Path=/", r.Header("Set-Cookie"), "Should have returned the headers received")
+	assert.Nil(t, cassette.Save(), "Should be nil")
+
+	data, err := ioutil.ReadFile(path)
+
+	assert.Nil(t, err, "Should be nil")
+
+	for _, secret := range []string{"hunter2", base64.StdEncoding.EncodeToString([]byte("user:hunter2")), "s3cr3t", "k3y"} {
+		assert.False(t, strings.Contains(string(data), secret), "Should have redacted "+secret)
+	}
+
+	cassette = load().WithMode(CassetteReplay)
+
+	r, err = doCassetteRequest(t, cassette, builder("another password", "another session", "another key"))
+
+	assert.Nil(t, err, "Should have ignored the redacted values")
+	assert.Equal(t, "session=[REDACTED]", r.Header("Set-Cookie"), "Should have replayed the redacted headers")
+	assert.Equal(t, int32(1), hits, "Should not have sent the request")
+
+	_, err = doCassetteRequest(t, cassette, NewRequestBuilder().WithUrl(ts.URL).WithHeader("Cookie", "session=s3cr3t"))
+
+	assert.NotNil(t, err, "Should not have matched a request without authorization")
+}
+
+func TestCassetteYaml(t *testing.T) {
+	var hits int32
+
+	ts := newCassetteServer(&hits)
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "gorequest")
+
+	assert.Nil(t, err, "Should be nil")
+
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"cassette.yaml", "cassette.yml"} {
+		path := filepath.Join(dir, name)
+		cassette, err := NewCassette(path)
+
+		assert.Nil(t, err, "Should be nil")
+
+		r, err := doCassetteRequest(t, cassette, NewRequestBuilder().WithMethod("POST").WithUrl(ts.URL).WithTextBody("line1\nline2"))
+
+		assert.Nil(t, err, "Should be nil")
+		assert.Nil(t, cassette.Save(), "Should be nil")
+
+		expected := r.Text()
+		data, err := ioutil.ReadFile(path)
+
+		assert.Nil(t, err, "Should be nil")
+		assert.True(t, strings.HasPrefix(string(data), "version: 1\n"), "Should have written YAML to "+name)
+
+		cassette, err = NewCassette(path)
+
+		assert.Nil(t, err, "Should be nil")
+
+		r, err = doCassetteRequest(t, cassette.WithMode(CassetteReplay), NewRequestBuilder().WithMethod("POST").WithUrl(ts.URL))
+
+		assert.Nil(t, err, "Should be nil")
+		assert.Equal(t, expected, r.Text(), "Should have replayed the response from "+name)
+		assert.Equal(t, http.StatusCreated, r.StatusCode(), "Should equal HTTP Status 201 (Created)")
+	}
+
+	assert.Equal(t, int32(2), hits, "Should have sent one request per cassette")
+}
+
+func TestCassetteInvalidFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gorequest")
+
+	assert.Nil(t, err, "Should be nil")
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "cassette.json")
+
+	for _, data := range []string{`{`, `{"version": 2}`, `{"version": 1, "interactions": [{"request": {}, "response": {"body": "!", "bodyEncoding": "base64"}}]}`} {
+		assert.Nil(t, ioutil.WriteFile(path, []byte(data), 0600), "Should be nil")
+
+		_, err := NewCassette(path)
+
+		assert.NotNil(t, err, "Should not be able to load "+data)
+	}
+
+	path = filepath.Join(dir, "cassette.yaml")
+
+	for _, data := range []string{"version: [", "version: 2"} {
+		assert.Nil(t, ioutil.WriteFile(path, []byte(data), 0600), "Should be nil")
+
+		_, err := NewCassette(path)
+
+		assert.NotNil(t, err, "Should not be able to load "+data)
+	}
+}
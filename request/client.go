@@ -0,0 +1,129 @@
+package request
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// REMARKS: Safe for concurrent use; reconfiguring the client only affects builders created afterwards.
+type client struct {
+	mutex      sync.RWMutex
+	httpClient *http.Client
+	timeout    time.Duration
+	baseUrl    string
+	middleware []Middleware
+	cache      *httpCache
+	logger     Logger
+	logPolicy  LogPolicy
+	recorder   HarRecorder
+}
+
+// REMARKS: Copy-on-write; builders created earlier keep the *http.Client (and transport) they were given.
+func (c *client) WithTransport(transport http.RoundTripper) Client {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	httpClient := *c.httpClient
+	httpClient.Transport = transport
+
+	c.httpClient = &httpClient
+
+	return c
+}
+
+func (c *client) WithTimeout(timeout time.Duration) Client {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.timeout = timeout
+
+	return c
+}
+
+func (c *client) WithBaseUrl(url string) Client {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.baseUrl = url
+
+	return c
+}
+
+func (c *client) WithMiddleware(middleware ...Middleware) Client {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.middleware = append(c.middleware, middleware...)
+
+	return c
+}
+
+// REMARKS: The builders created from the client share the store, and therefore the cached responses, and the
+// background revalidations.
+func (c *client) WithCache(store CacheStore) Client {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.cache = nil
+
+	if store != nil {
+		c.cache = newHttpCache(store)
+	}
+
+	return c
+}
+
+func (c *client) WithLogger(logger Logger) Client {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.logger = logger
+
+	return c
+}
+
+func (c *client) WithLogPolicy(policy LogPolicy) Client {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.logPolicy = policy
+
+	return c
+}
+
+func (c *client) WithHarRecorder(recorder HarRecorder) Client {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.recorder = recorder
+
+	return c
+}
+
+func (c *client) HttpClient() *http.Client {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.httpClient
+}
+
+// REMARKS: Builders created from the same client share its *http.Client, and therefore its connection pool.
+// The client's middleware runs before (outside of) any middleware added to the builder.
+func (c *client) NewRequestBuilder() RequestBuilder {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	b := NewRequestBuilder().
+		WithHttpClient(c.httpClient).
+		WithTimeout(c.timeout).
+		WithBaseUrl(c.baseUrl).
+		WithMiddleware(c.middleware...).
+		WithLogger(c.logger).
+		WithLogPolicy(c.logPolicy).
+		WithHarRecorder(c.recorder).(*requestBuilder)
+
+	b.cache = c.cache
+
+	return b
+}
@@ -0,0 +1,183 @@
+package request
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewClientWithDefaults(t *testing.T) {
+	c := NewClient()
+
+	assert.NotNil(t, c.HttpClient(), "Should not be nil")
+
+	transport, ok := c.HttpClient().Transport.(*http.Transport)
+
+	assert.True(t, ok, "Should be an *http.Transport")
+	assert.Equal(t, defaultMaxIdleConnsPerHost, transport.MaxIdleConnsPerHost, "Should equal default idle connections per host")
+}
+
+func TestClientNewRequestBuilder(t *testing.T) {
+	transport := NewTransport()
+	c := NewClient().WithTransport(transport).WithTimeout(45 * time.Second)
+
+	r1, err := c.NewRequestBuilder().WithUrl(POSTMAN_ECHO_ROOT).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	hc := r1.getUnderlyingHttpClient()
+
+	assert.Equal(t, 45*time.Second, hc.Timeout, "Should equal 45 seconds")
+	assert.True(t, transport == hc.Transport, "Should share the client's transport")
+}
+
+func TestRequestBuilderWithHttpClient(t *testing.T) {
+	transport := NewTransport()
+	base := &http.Client{Transport: transport, Timeout: 10 * time.Second}
+
+	r1, err := NewRequestBuilder().WithHttpClient(base).WithUrl(POSTMAN_ECHO_ROOT).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	hc := r1.getUnderlyingHttpClient()
+
+	assert.Equal(t, 10*time.Second, hc.Timeout, "Should equal the client's timeout")
+	assert.True(t, transport == hc.Transport, "Should share the client's transport")
+
+	r2, err := NewRequestBuilder().WithHttpClient(base).WithTimeout(time.Second).WithUrl(POSTMAN_ECHO_ROOT).Build()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, time.Second, r2.getUnderlyingHttpClient().Timeout, "Should equal the builder's timeout")
+	assert.Equal(t, 10*time.Second, base.Timeout, "Should not modify the client")
+}
+
+func TestRequestBuilderWithTransport(t *testing.T) {
+	transport := NewTransport()
+
+	r1, err := NewRequestBuilder().WithTransport(transport).WithUrl(POSTMAN_ECHO_ROOT).Build()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.True(t, transport == r1.getUnderlyingHttpClient().Transport, "Should use the transport")
+	assert.True(t, transport != defaultHttpClient.Transport, "Should not modify the default client")
+}
+
+func TestClientReusesConnections(t *testing.T) {
+	ts, conns := newConnCountingServer()
+	defer ts.Close()
+
+	c := NewClient()
+
+	for i := 0; i < 10; i++ {
+		req, err := c.NewRequestBuilder().WithUrl(ts.URL).Build()
+
+		assert.Nil(t, err, "Should be nil")
+
+		_, err = req.Do()
+
+		assert.Nil(t, err, "Should be nil")
+	}
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(conns), "Should have opened a single connection")
+}
+
+func BenchmarkSharedClient(b *testing.B) {
+	ts, conns := newConnCountingServer()
+	defer ts.Close()
+
+	c := NewClient()
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		req, _ := c.NewRequestBuilder().WithUrl(ts.URL).Build()
+		req.Do()
+	}
+
+	b.ReportMetric(float64(atomic.LoadInt32(conns)), "conns")
+}
+
+func BenchmarkTransportPerRequest(b *testing.B) {
+	ts, conns := newConnCountingServer()
+	defer ts.Close()
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		transport := NewTransport()
+		req, _ := NewRequestBuilder().WithTransport(transport).WithUrl(ts.URL).Build()
+		req.Do()
+		transport.CloseIdleConnections()
+	}
+
+	b.ReportMetric(float64(atomic.LoadInt32(conns)), "conns")
+}
+
+func newConnCountingServer() (*httptest.Server, *int32) {
+	var conns int32
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(resp, "Hello World")
+	}))
+	ts.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	ts.Start()
+
+	return ts, &conns
+}
+
+func TestClientWithBaseUrl(t *testing.T) {
+	c := NewClient().WithBaseUrl(POSTMAN_ECHO_ROOT)
+
+	r1, err := c.NewRequestBuilder().WithUrl("/users/{id}").WithPathParam("id", "42").Build()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, POSTMAN_ECHO_ROOT+"/users/42", r1.getUnderlyingRequest().URL.String(), "Should equal URL")
+}
+
+func TestClientConcurrently(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(resp, "%s", req.URL.Query().Get("id"))
+	}))
+	defer ts.Close()
+
+	c := NewClient().WithBaseUrl(ts.URL)
+	noop := func(next RoundTripperFunc) RoundTripperFunc { return next }
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+
+		go func(id int) {
+			defer wg.Done()
+
+			req, err := c.NewRequestBuilder().WithUrl("/").WithQueryParam("id", fmt.Sprintf("%d", id)).Build()
+
+			assert.Nil(t, err, "Should be nil")
+
+			r, err := req.Do()
+
+			assert.Nil(t, err, "Should be nil")
+			assert.Equal(t, fmt.Sprintf("%d", id), r.Text(), "Should equal response body")
+		}(i)
+
+		// Reconfigure the client while requests are being made.
+		go func() {
+			defer wg.Done()
+
+			c.WithTimeout(10 * time.Second).WithMiddleware(noop).WithTransport(c.HttpClient().Transport)
+		}()
+	}
+
+	wg.Wait()
+}
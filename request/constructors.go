@@ -1,7 +1,11 @@
 package request
 
 import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
 	"net/http"
+	"net/url"
 	"time"
 )
 
@@ -11,17 +15,228 @@ func NewRequestBuilder() RequestBuilder {
 	return &requestBuilder{
 		auth:    newAuthNone(),
 		headers: make(map[string]string),
+		query:   make(url.Values),
 		method:  defaultMethod,
 		timeout: defaultTimeout,
+		ctx:     context.Background(),
+		retry:   newRetryNone(),
+		client:  defaultHttpClient,
 	}
 }
 
-func newHttpClient(timeout time.Duration) *http.Client {
-	return &http.Client{
-		Timeout: timeout,
+// REMARKS: Parses a curl command (e.g. copied from API docs or a browser) into a builder. Supports -X, -H, -d,
+// --data-binary, --data-raw, --data-urlencode, --json, -F, -u (with --digest or --aws-sigv4), -G, -I, -A, -e, -b and
+// -m; options that only affect curl's output are ignored, and any other option is an error.
+func FromCurl(command string) (RequestBuilder, error) {
+	return parseCurl(command)
+}
+
+func NewMultipartBuilder() MultipartBuilder {
+	return &multipartBuilder{}
+}
+
+func NewBasicAuth(username, password string) AuthorizationMethod {
+	return newAuthBasic(username, password)
+}
+
+func NewBearerAuth(token string) AuthorizationMethod {
+	return newAuthBearer(token)
+}
+
+// REMARKS: name is the header, query parameter or cookie name, depending on placement.
+func NewApiKeyAuth(name, value string, placement ApiKeyPlacement) AuthorizationMethod {
+	return newAuthApiKey(name, value, placement)
+}
+
+func NewDigestAuth(username, password string) AuthorizationHandler {
+	return newAuthDigest(username, password)
+}
+
+func NewAwsSigV4(accessKeyId, secretAccessKey, region, service string) AwsSigV4 {
+	return newAuthAwsSigV4(accessKeyId, secretAccessKey, region, service)
+}
+
+func NewHmacSignature(keyId string, key []byte) MessageSignature {
+	return newAuthMessageSignature(keyId, &hmacSha256Algorithm{key: key})
+}
+
+func NewRsaPssSignature(keyId string, key *rsa.PrivateKey) MessageSignature {
+	return newAuthMessageSignature(keyId, &rsaPssAlgorithm{privateKey: key, publicKey: &key.PublicKey})
+}
+
+func NewEd25519Signature(keyId string, key ed25519.PrivateKey) MessageSignature {
+	return newAuthMessageSignature(keyId, &ed25519Algorithm{privateKey: key, publicKey: key.Public().(ed25519.PublicKey)})
+}
+
+// REMARKS: keys returns the key to verify the signatures made with keyId (see messageVerifier for the key types).
+func NewMessageVerifier(keys func(keyId string) (interface{}, error)) MessageVerifier {
+	return newMessageVerifier(keys)
+}
+
+func NewOAuth2ClientCredentials(tokenUrl, clientId, clientSecret string) OAuth2 {
+	return newAuthOAuth2(tokenUrl, clientId, clientSecret, url.Values{
+		"grant_type": []string{"client_credentials"},
+	})
+}
+
+func NewOAuth2Password(tokenUrl, clientId, clientSecret, username, password string) OAuth2 {
+	return newAuthOAuth2(tokenUrl, clientId, clientSecret, url.Values{
+		"grant_type": []string{"password"},
+		"username":   []string{username},
+		"password":   []string{password},
+	})
+}
+
+// REMARKS: For a refresh token obtained elsewhere (e.g. through an authorization code flow).
+func NewOAuth2RefreshToken(tokenUrl, clientId, clientSecret, refreshToken string) OAuth2 {
+	auth := newAuthOAuth2(tokenUrl, clientId, clientSecret, nil)
+	auth.refreshToken = refreshToken
+	auth.refreshedOnly = true
+
+	return auth
+}
+
+func NewClient() Client {
+	return &client{
+		httpClient: &http.Client{
+			Transport: NewTransport(),
+		},
+		timeout: defaultTimeout,
 	}
 }
 
+func NewSession() Session {
+	jar := newSessionJar()
+
+	return &session{
+		client: &client{
+			httpClient: &http.Client{
+				Transport: NewTransport(),
+				Jar:       jar,
+			},
+			timeout: defaultTimeout,
+		},
+		jar: jar,
+	}
+}
+
+// REMARKS: Keeps the maxEntries most recently used responses (defaultCacheMaxEntries if maxEntries <= 0).
+func NewMemoryCacheStore(maxEntries int) CacheStore {
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheMaxEntries
+	}
+
+	return newMemoryCacheStore(maxEntries)
+}
+
+// REMARKS: Keeps one file per response in dir, which is created if needed. Entries are never evicted.
+func NewDiskCacheStore(dir string) (CacheStore, error) {
+	return newDiskCacheStore(dir)
+}
+
+// REMARKS: Redacts the default headers and fields, see logPolicy.
+func NewLogPolicy() LogPolicy {
+	return newLogPolicy()
+}
+
+// REMARKS: Bodies are truncated to defaultHarMaxBodySize bytes, and redacted like the logs (see NewLogPolicy).
+func NewHarRecorder() HarRecorder {
+	return newHarRecorder()
+}
+
+// REMARKS: Returns a builder for each entry of the HAR file, e.g. to replay recorded traffic. Fails on entries whose
+// request body was truncated; redacted values must be set again before the requests are sent.
+func LoadHar(path string) ([]RequestBuilder, error) {
+	return loadHar(path)
+}
+
+// REMARKS: Loads the cassette file at path, if it exists; files named *.yaml or *.yml are YAML, others JSON. Requests
+// are replayed from it, and recorded when they are missing (see WithMode), with the default transport (see
+// NewTransport).
+func NewCassette(path string) (Cassette, error) {
+	return newCassette(path)
+}
+
+// REMARKS: Methods are compared case-insensitively.
+func MatchMethod(request, recorded *CassetteRequest) bool {
+	return matchCassetteMethod(request, recorded)
+}
+
+// REMARKS: The query parameters may be in any order.
+func MatchUrl(request, recorded *CassetteRequest) bool {
+	return matchCassetteUrl(request, recorded)
+}
+
+func MatchBody(request, recorded *CassetteRequest) bool {
+	return matchCassetteBody(request, recorded)
+}
+
+// REMARKS: The headers must have the same values, in the same order; a header that is missing from both matches.
+func MatchHeaders(names ...string) CassetteMatcher {
+	return matchCassetteHeaders(names)
+}
+
+// REMARKS: Starts from the settings of http.DefaultTransport (proxy from environment, dial and TLS handshake timeouts, HTTP/2)
+// and raises the number of idle connections kept per host, which is only 2 by default.
+func NewTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+
+	return transport
+}
+
+func NewBackoffRetryPolicy(maxAttempts int) BackoffRetryPolicy {
+	return &retryBackoff{
+		maxAttempts:   maxAttempts,
+		baseDelay:     defaultRetryBaseDelay,
+		maxDelay:      defaultRetryMaxDelay,
+		jitter:        true,
+		idempotent:    true,
+		retryOnStatus: defaultRetryOnStatus,
+		retryOnError:  defaultRetryOnError,
+	}
+}
+
+func newHttpClient(timeout time.Duration) *http.Client {
+	return deriveHttpClient(defaultHttpClient, timeout)
+}
+
+// REMARKS: http.Client only holds configuration, so a shallow copy is cheap and keeps sharing the base client's transport.
+func deriveHttpClient(base *http.Client, timeout time.Duration) *http.Client {
+	client := *base
+	client.Timeout = timeout
+
+	return &client
+}
+
 var defaultAuthorization AuthorizationMethod = newAuthNone()
 var defaultMethod string = "GET"
 var defaultTimeout time.Duration = 30 * time.Second
+var defaultRetryBaseDelay time.Duration = 100 * time.Millisecond
+var defaultRetryMaxDelay time.Duration = 30 * time.Second
+var defaultPartContentType string = "application/octet-stream"
+var defaultAwsChunkSize int = 64 * 1024
+var defaultSignatureLabel string = "sig1"
+var defaultSignatureComponents = []string{"@method", "@authority", "@path"}
+var defaultVerifierMaxBodySize int = 10 * 1024 * 1024
+var defaultOAuth2Leeway time.Duration = 10 * time.Second
+var defaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+var defaultRedactedFields = []string{"password", "client_secret", "access_token", "refresh_token", "id_token"}
+var defaultHarMaxBodySize int = 1024 * 1024
+var defaultCacheMaxEntries int = 1000
+var defaultCassetteMatchers = []CassetteMatcher{MatchMethod, MatchUrl}
+var defaultMaxIdleConnsPerHost int = 16
+var defaultHttpClient *http.Client = &http.Client{Transport: NewTransport()}
+
+// REMARKS: Bodies are ignored for the methods whose specification gives them no meaning (RFC 2616 and RFC 9110).
+var defaultBodyPolicies = map[string]BodyPolicy{
+	http.MethodGet:     BodyIgnored,
+	http.MethodHead:    BodyIgnored,
+	http.MethodDelete:  BodyIgnored,
+	http.MethodTrace:   BodyIgnored,
+	http.MethodConnect: BodyIgnored,
+	http.MethodPost:    BodyAllowed,
+	http.MethodPut:     BodyAllowed,
+	http.MethodPatch:   BodyAllowed,
+	http.MethodOptions: BodyAllowed,
+}
@@ -11,7 +11,9 @@ func TestNewRequestBuilderWithDefaults(t *testing.T) {
 	builder := NewRequestBuilder()
 	builder.WithUrl(POSTMAN_ECHO_ROOT)
 
-	r1 := builder.Build()
+	r1, err := builder.Build()
+
+	assert.Nil(t, err, "Should be nil")
 
 	assert.NotNil(t, r1, "Should not be nil")
 
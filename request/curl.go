@@ -0,0 +1,683 @@
+package request
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// REMARKS: The options of a curl command that FromCurl understands. Data is kept as curl sends it: already encoded,
+// and joined with "&" (or as is for --json).
+type curlCommand struct {
+	method   string
+	url      string
+	header   http.Header
+	data     []string
+	json     bool
+	get      bool
+	head     bool
+	user     string
+	digest   bool
+	awsSigV4 string
+	bearer   string
+	form     MultipartBuilder
+	timeout  time.Duration
+}
+
+// REMARKS: Options that don't change the request (output, progress, redirects, TLS verification, ...).
+var curlIgnoredOptions = map[string]bool{
+	"-s": true, "--silent": true, "-S": true, "--show-error": true, "-v": true, "--verbose": true,
+	"-i": true, "--include": true, "-L": true, "--location": true, "--compressed": true, "-f": true,
+	"--fail": true, "-g": true, "--globoff": true, "-k": true, "--insecure": true, "-#": true,
+	"--progress-bar": true, "-N": true, "--no-buffer": true,
+}
+
+var curlIgnoredValueOptions = map[string]bool{
+	"-o": true, "--output": true, "-w": true, "--write-out": true, "-c": true, "--cookie-jar": true,
+	"--connect-timeout": true, "--retry": true,
+}
+
+var curlFlagOptions = map[string]bool{
+	"-G": true, "--get": true, "-I": true, "--head": true, "--digest": true, "--basic": true,
+}
+
+var curlShortOptions = map[string]string{
+	"-X": "--request", "-H": "--header", "-d": "--data", "-u": "--user", "-F": "--form", "-A": "--user-agent",
+	"-e": "--referer", "-b": "--cookie", "-m": "--max-time", "-G": "--get", "-I": "--head",
+}
+
+var curlSafeWord = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// REMARKS: Only the authorization methods curl supports are rendered: Basic (from the Authorization header),
+// Digest and AWS Signature Version 4; the headers of the other methods (OAuth2, message signatures) are added when
+// the request is sent, and are missing from the command. A body that can only be read once is read from stdin (@-).
+func formatCurl(request *http.Request, auth AuthorizationMethod) string {
+	var body []byte
+	streamed := false
+
+	if request.GetBody != nil {
+		if reader, err := request.GetBody(); err == nil {
+			body, _ = ioutil.ReadAll(reader)
+			reader.Close()
+		}
+	} else {
+		streamed = request.Body != nil && request.Body != http.NoBody
+	}
+
+	hasBody := len(body) > 0 || streamed
+	args := []string{"curl"}
+
+	switch {
+	case request.Method == http.MethodHead && !hasBody:
+		args = append(args, "--head")
+	case request.Method == http.MethodGet && !hasBody, request.Method == http.MethodPost && hasBody:
+	default:
+		args = append(args, "-X", request.Method)
+	}
+
+	args = append(args, shellQuote(request.URL.String()))
+
+	header := request.Header.Clone()
+
+	if username, password, ok := request.BasicAuth(); ok {
+		args = append(args, "-u", shellQuote(username+":"+password))
+		header.Del("Authorization")
+	}
+
+	for _, method := range flattenAuth(auth) {
+		switch a := method.(type) {
+		case *authDigest:
+			args = append(args, "--digest", "-u", shellQuote(a.username+":"+a.password))
+		case *authAwsSigV4:
+			args = append(args, "--aws-sigv4", shellQuote("aws:amz:"+a.region+":"+a.service), "-u", shellQuote(a.accessKeyId+":"+a.secretAccessKey))
+
+			if a.sessionToken != "" {
+				header.Set("X-Amz-Security-Token", a.sessionToken)
+			}
+		}
+	}
+
+	if request.Host != "" && request.Host != request.URL.Host {
+		header.Set("Host", request.Host)
+	}
+
+	header.Del("Content-Length")
+
+	names := make([]string, 0, len(header))
+
+	for name := range header {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, value := range header[name] {
+			args = append(args, "-H", shellQuote(name+": "+value))
+		}
+	}
+
+	switch {
+	case streamed, len(body) > 0 && (!utf8.Valid(body) || bytes.IndexByte(body, 0) >= 0):
+		args = append(args, "--data-binary", "@-")
+	case len(body) > 0:
+		args = append(args, "--data-binary", shellQuote(string(body)))
+	}
+
+	return strings.Join(args, " ")
+}
+
+func parseCurl(command string) (RequestBuilder, error) {
+	words, err := splitShellWords(command)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(words) > 0 && words[0] == "curl" {
+		words = words[1:]
+	}
+
+	c := &curlCommand{header: make(http.Header)}
+
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+
+		if !strings.HasPrefix(word, "-") || word == "-" {
+			if c.url != "" {
+				return nil, fmt.Errorf("Only one URL is supported, got %q and %q.", c.url, word)
+			}
+
+			c.url = word
+			continue
+		}
+
+		// REMARKS: Short options can be grouped (-sSL), and take their value from the rest of the word (-XPOST)
+		// or from the next one.
+		options := []string{word}
+
+		if !strings.HasPrefix(word, "--") && len(word) > 2 {
+			options = nil
+
+			for j := 1; j < len(word); j++ {
+				option := "-" + word[j:j+1]
+
+				if curlIgnoredOptions[option] || curlFlagOptions[option] {
+					options = append(options, option)
+					continue
+				}
+
+				if j+1 == len(word) {
+					options = append(options, option)
+				} else if err := c.apply(option, word[j+1:]); err != nil {
+					return nil, err
+				}
+
+				break
+			}
+		}
+
+		for _, option := range options {
+			if curlIgnoredOptions[option] {
+				continue
+			}
+
+			if curlFlagOptions[option] {
+				if err := c.apply(option, ""); err != nil {
+					return nil, err
+				}
+
+				continue
+			}
+
+			if i+1 >= len(words) {
+				return nil, fmt.Errorf("Missing value for curl option %q.", option)
+			}
+
+			i++
+
+			if err := c.apply(option, words[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return c.builder()
+}
+
+// ***********************************************
+// ********** Private methods/functions **********
+// ***********************************************
+
+func (c *curlCommand) apply(option, value string) error {
+	if long, ok := curlShortOptions[option]; ok {
+		option = long
+	}
+
+	switch option {
+	case "--request":
+		c.method = value
+	case "--url":
+		c.url = value
+	case "--header":
+		return c.addHeader(value)
+	case "--user-agent":
+		c.header.Set("User-Agent", value)
+	case "--referer":
+		c.header.Set("Referer", value)
+	case "--cookie":
+		if !strings.Contains(value, "=") {
+			return fmt.Errorf("Unsupported curl cookie file %q.", value)
+		}
+
+		c.header.Set("Cookie", value)
+	case "--data", "--data-ascii":
+		data, err := readCurlData(value, true)
+
+		if err != nil {
+			return err
+		}
+
+		c.data = append(c.data, data)
+	case "--data-binary":
+		data, err := readCurlData(value, false)
+
+		if err != nil {
+			return err
+		}
+
+		c.data = append(c.data, data)
+	case "--data-raw":
+		c.data = append(c.data, value)
+	case "--data-urlencode":
+		data, err := urlencodeCurlData(value)
+
+		if err != nil {
+			return err
+		}
+
+		c.data = append(c.data, data)
+	case "--json":
+		data, err := readCurlData(value, false)
+
+		if err != nil {
+			return err
+		}
+
+		c.data = append(c.data, data)
+		c.json = true
+	case "--form", "--form-string":
+		return c.addFormPart(value, option == "--form")
+	case "--user":
+		c.user = value
+	case "--digest":
+		c.digest = true
+	case "--basic":
+		c.digest = false
+	case "--aws-sigv4":
+		c.awsSigV4 = value
+	case "--oauth2-bearer":
+		c.bearer = value
+	case "--max-time":
+		seconds, err := strconv.ParseFloat(value, 64)
+
+		if err != nil {
+			return fmt.Errorf("Invalid curl max time %q.", value)
+		}
+
+		c.timeout = time.Duration(seconds * float64(time.Second))
+	case "--get":
+		c.get = true
+	case "--head":
+		c.head = true
+	default:
+		if curlIgnoredValueOptions[option] {
+			return nil
+		}
+
+		return fmt.Errorf("Unsupported curl option %q.", option)
+	}
+
+	return nil
+}
+
+// REMARKS: "Name: value" sets a header; "Name;" sets it with an empty value, and "Name:" (which removes one of
+// curl's own headers) is ignored.
+func (c *curlCommand) addHeader(value string) error {
+	if strings.HasPrefix(value, "@") {
+		return fmt.Errorf("Unsupported curl header file %q.", value)
+	}
+
+	if i := strings.Index(value, ":"); i > 0 {
+		if v := strings.TrimSpace(value[i+1:]); v != "" {
+			c.header.Add(strings.TrimSpace(value[:i]), v)
+		}
+
+		return nil
+	}
+
+	if strings.HasSuffix(value, ";") {
+		c.header.Add(strings.TrimSuffix(value, ";"), "")
+
+		return nil
+	}
+
+	return fmt.Errorf("Invalid curl header %q.", value)
+}
+
+// REMARKS: Supports name=value, name=@path[;type=...][;filename=...] (a file) and name=<path (a field read from a file).
+func (c *curlCommand) addFormPart(value string, special bool) error {
+	i := strings.Index(value, "=")
+
+	if i <= 0 {
+		return fmt.Errorf("Invalid curl form part %q.", value)
+	}
+
+	if c.form == nil {
+		c.form = NewMultipartBuilder()
+	}
+
+	name, content := value[:i], value[i+1:]
+
+	switch {
+	case special && strings.HasPrefix(content, "@"):
+		params := strings.Split(content[1:], ";")
+		path, filename, contentType := params[0], filepath.Base(params[0]), ""
+
+		for _, param := range params[1:] {
+			if key, v, ok := splitMember(param); ok {
+				switch strings.ToLower(key) {
+				case "type":
+					contentType = v
+				case "filename":
+					filename = strings.Trim(v, `"`)
+				}
+			}
+		}
+
+		if len(params) == 1 {
+			c.form.WithFile(name, path)
+
+			return nil
+		}
+
+		data, err := ioutil.ReadFile(path)
+
+		if err != nil {
+			return err
+		}
+
+		if contentType == "" {
+			contentType = defaultPartContentType
+		}
+
+		c.form.WithReader(name, filename, contentType, bytes.NewReader(data))
+	case special && strings.HasPrefix(content, "<"):
+		data, err := ioutil.ReadFile(content[1:])
+
+		if err != nil {
+			return err
+		}
+
+		c.form.WithField(name, string(data))
+	default:
+		c.form.WithField(name, content)
+	}
+
+	return nil
+}
+
+func (c *curlCommand) builder() (RequestBuilder, error) {
+	if c.url == "" {
+		return nil, errors.New("The curl command has no URL.")
+	}
+
+	if c.form != nil && len(c.data) > 0 {
+		return nil, errors.New("The curl command can't have both data and form parts.")
+	}
+
+	rawUrl := c.url
+
+	if !isAbsoluteUrl(rawUrl) {
+		rawUrl = "http://" + rawUrl
+	}
+
+	separator := "&"
+
+	if c.json {
+		separator = ""
+	}
+
+	data := strings.Join(c.data, separator)
+
+	// REMARKS: With -G, the data is sent in the query rather than the body.
+	if c.get && len(c.data) > 0 {
+		if strings.Contains(rawUrl, "?") {
+			rawUrl += "&" + data
+		} else {
+			rawUrl += "?" + data
+		}
+	}
+
+	b := NewRequestBuilder().WithUrl(rawUrl)
+
+	switch {
+	case c.method != "":
+		b.WithMethod(c.method)
+	case c.head:
+		b.WithMethod(http.MethodHead)
+	case c.form != nil || (len(c.data) > 0 && !c.get):
+		b.WithMethod(http.MethodPost)
+	}
+
+	if c.json {
+		c.setDefaultHeader("Content-Type", "application/json")
+		c.setDefaultHeader("Accept", "application/json")
+	}
+
+	switch {
+	case c.form != nil:
+		b.WithMultipartBody(c.form)
+	case len(c.data) > 0 && !c.get:
+		c.setDefaultHeader("Content-Type", "application/x-www-form-urlencoded")
+		b.WithTextBody(data)
+	}
+
+	// REMARKS: The builder keeps one value per header; repeated headers are joined as a list.
+	for name, values := range c.header {
+		b.WithHeader(name, strings.Join(values, ", "))
+	}
+
+	username, password := c.user, ""
+
+	if i := strings.Index(c.user, ":"); i >= 0 {
+		username, password = c.user[:i], c.user[i+1:]
+	}
+
+	switch {
+	case c.awsSigV4 != "":
+		providers := strings.Split(c.awsSigV4, ":")
+
+		if len(providers) < 4 {
+			return nil, fmt.Errorf("The curl --aws-sigv4 option %q needs a region and a service.", c.awsSigV4)
+		}
+
+		signer := NewAwsSigV4(username, password, providers[2], providers[3])
+
+		if token := c.header.Get("X-Amz-Security-Token"); token != "" {
+			signer.WithSessionToken(token)
+		}
+
+		b.WithAuth(signer)
+	case c.bearer != "":
+		b.WithBearerAuth(c.bearer)
+	case c.user != "" && c.digest:
+		b.WithDigestAuth(username, password)
+	case c.user != "":
+		b.WithBasicAuth(username, password)
+	}
+
+	if c.timeout > 0 {
+		b.WithTimeout(c.timeout)
+	}
+
+	return b, nil
+}
+
+func (c *curlCommand) setDefaultHeader(name, value string) {
+	if c.header.Get(name) == "" {
+		c.header.Set(name, value)
+	}
+}
+
+// REMARKS: @path reads the data from a file; -d strips its newlines, as curl does.
+// REMARKS: "@-" reads the body from stdin, which AsCurl renders for the bodies it can't show; there is no stdin to
+// read it from here.
+func readCurlData(value string, stripNewlines bool) (string, error) {
+	if !strings.HasPrefix(value, "@") {
+		return value, nil
+	}
+
+	if value == "@-" {
+		return "", errors.New("The body is read from stdin (@-), which is not supported; write it to a file and use @file instead.")
+	}
+
+	data, err := ioutil.ReadFile(value[1:])
+
+	if err != nil {
+		return "", err
+	}
+
+	if stripNewlines {
+		return strings.NewReplacer("\r", "", "\n", "").Replace(string(data)), nil
+	}
+
+	return string(data), nil
+}
+
+// REMARKS: Supports content, =content, name=content, @path and name@path.
+func urlencodeCurlData(value string) (string, error) {
+	name, content := "", value
+
+	if i := strings.IndexAny(value, "=@"); i >= 0 {
+		name, content = value[:i], value[i+1:]
+
+		if value[i] == '@' {
+			data, err := ioutil.ReadFile(content)
+
+			if err != nil {
+				return "", err
+			}
+
+			content = string(data)
+		}
+	}
+
+	if name == "" {
+		return url.QueryEscape(content), nil
+	}
+
+	return name + "=" + url.QueryEscape(content), nil
+}
+
+func flattenAuth(auth AuthorizationMethod) []AuthorizationMethod {
+	if chain, ok := auth.(*authChain); ok {
+		return chain.methods
+	}
+
+	return []AuthorizationMethod{auth}
+}
+
+func shellQuote(s string) string {
+	if curlSafeWord.MatchString(s) {
+		return s
+	}
+
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// REMARKS: Splits a command the way a POSIX shell would: single and double quotes, backslash escapes and line
+// continuations, as well as the $'...' strings of bash (used by the "Copy as cURL" of browsers).
+func splitShellWords(command string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+
+	for i := 0; i < len(command); i++ {
+		ch := command[i]
+
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case ch == '\\':
+			if i+1 < len(command) {
+				i++
+
+				if command[i] == '\n' {
+					continue
+				}
+
+				word.WriteByte(command[i])
+			}
+
+			inWord = true
+		case ch == '\'':
+			end := strings.IndexByte(command[i+1:], '\'')
+
+			if end < 0 {
+				return nil, errors.New("Unterminated single quote in curl command.")
+			}
+
+			word.WriteString(command[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case ch == '$' && i+1 < len(command) && command[i+1] == '\'':
+			n, err := readAnsiCString(command[i+2:], &word)
+
+			if err != nil {
+				return nil, err
+			}
+
+			i += n + 1
+			inWord = true
+		case ch == '"':
+			i++
+
+			for ; i < len(command) && command[i] != '"'; i++ {
+				if command[i] == '\\' && i+1 < len(command) && strings.IndexByte("\\\"$`\n", command[i+1]) >= 0 {
+					i++
+
+					if command[i] == '\n' {
+						continue
+					}
+				}
+
+				word.WriteByte(command[i])
+			}
+
+			if i >= len(command) {
+				return nil, errors.New("Unterminated double quote in curl command.")
+			}
+
+			inWord = true
+		default:
+			word.WriteByte(ch)
+			inWord = true
+		}
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
+
+// REMARKS: Reads the content of a $'...' string up to its closing quote, and returns the number of bytes consumed.
+func readAnsiCString(s string, word *strings.Builder) (int, error) {
+	escapes := map[byte]byte{'n': '\n', 't': '\t', 'r': '\r', '\\': '\\', '\'': '\'', '"': '"', 'a': '\a', 'b': '\b', 'e': 0x1b, 'f': '\f', 'v': '\v'}
+
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\'':
+			return i + 1, nil
+		case s[i] == '\\' && i+1 < len(s):
+			i++
+
+			if b, ok := escapes[s[i]]; ok {
+				word.WriteByte(b)
+			} else if s[i] == 'x' && i+2 < len(s) {
+				if b, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+					word.WriteByte(byte(b))
+					i += 2
+				}
+			} else if s[i] == 'u' && i+4 < len(s) {
+				if r, err := strconv.ParseUint(s[i+1:i+5], 16, 32); err == nil {
+					word.WriteRune(rune(r))
+					i += 4
+				}
+			} else {
+				word.WriteByte('\\')
+				word.WriteByte(s[i])
+			}
+		default:
+			word.WriteByte(s[i])
+		}
+	}
+
+	return 0, errors.New("Unterminated $'...' string in curl command.")
+}
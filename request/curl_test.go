@@ -0,0 +1,185 @@
+package request
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAsCurl(t *testing.T) {
+	tests := []struct {
+		builder  RequestBuilder
+		expected string
+	}{
+		{
+			NewRequestBuilder().WithUrl("https://example.com/users?page=2"),
+			"curl 'https://example.com/users?page=2'",
+		},
+		{
+			NewRequestBuilder().WithMethod("POST").WithUrl("https://example.com/users").WithJsonBody(`{"name":"O'Brien"}`).WithHeader("X-Request-Id", "42"),
+			`curl https://example.com/users -H 'Content-Type: application/json' -H 'X-Request-Id: 42' --data-binary '{"name":"O'\''Brien"}'`,
+		},
+		{
+			NewRequestBuilder().WithMethod("PUT").WithUrl("https://example.com/users/1").WithTextBody("Hello World").WithBasicAuth("user", "pa ss"),
+			`curl -X PUT https://example.com/users/1 -u 'user:pa ss' -H 'Content-Type: text/plain' --data-binary 'Hello World'`,
+		},
+		{
+			NewRequestBuilder().WithMethod("DELETE").WithUrl("https://example.com/users/1").WithDigestAuth("user", "password").WithCookie(&http.Cookie{Name: "a", Value: "b"}),
+			`curl -X DELETE https://example.com/users/1 --digest -u user:password -H 'Cookie: a=b'`,
+		},
+		{
+			NewRequestBuilder().WithMethod("HEAD").WithUrl("https://example.com/").WithAuth(NewAwsSigV4("AKID", "SECRET", "us-east-1", "s3").WithSessionToken("TOKEN")),
+			`curl --head https://example.com/ --aws-sigv4 aws:amz:us-east-1:s3 -u AKID:SECRET -H 'X-Amz-Security-Token: TOKEN'`,
+		},
+		{
+			NewRequestBuilder().WithMethod("POST").WithUrl("https://example.com/upload").WithBodyReader(ioutil.NopCloser(strings.NewReader("data")), "application/octet-stream"),
+			`curl https://example.com/upload -H 'Content-Type: application/octet-stream' --data-binary @-`,
+		},
+	}
+
+	for _, test := range tests {
+		req, err := test.builder.Build()
+
+		assert.Nil(t, err, "Should be nil")
+		assert.Equal(t, test.expected, req.AsCurl(), "Should equal curl command")
+	}
+}
+
+func TestFromCurl(t *testing.T) {
+	tests := []struct {
+		command  string
+		expected string
+	}{
+		{
+			`curl https://example.com/users`,
+			`curl https://example.com/users`,
+		},
+		{
+			`curl -X POST "https://example.com/users" \
+			  -H 'Content-Type: application/json' \
+			  -d '{"name": "john"}'`,
+			`curl https://example.com/users -H 'Content-Type: application/json' --data-binary '{"name": "john"}'`,
+		},
+		{
+			`curl -sSL -XPATCH https://example.com/users/1 -d name=john -d age=42 -u user:password -A "my agent"`,
+			`curl -X PATCH https://example.com/users/1 -u user:password -H 'Content-Type: application/x-www-form-urlencoded' -H 'User-Agent: my agent' --data-binary 'name=john&age=42'`,
+		},
+		{
+			`curl --json '{"a":1}' example.com/api`,
+			`curl http://example.com/api -H 'Accept: application/json' -H 'Content-Type: application/json' --data-binary '{"a":1}'`,
+		},
+		{
+			`curl localhost:8080/login?next=https://app.example.com/`,
+			`curl 'http://localhost:8080/login?next=https://app.example.com/'`,
+		},
+		{
+			`curl -G https://example.com/search -d q=go --data-urlencode 'lang=en us'`,
+			`curl 'https://example.com/search?q=go&lang=en+us'`,
+		},
+		{
+			`curl $'https://example.com/items' -H $'X-Name: it\'s\x21' --data-binary $'line1\nline2' --compressed`,
+			`curl https://example.com/items -H 'Content-Type: application/x-www-form-urlencoded' -H 'X-Name: it'\''s!' --data-binary 'line1` + "\n" + `line2'`,
+		},
+		{
+			`curl -I --digest -u user:password https://example.com/`,
+			`curl --head https://example.com/ --digest -u user:password`,
+		},
+		{
+			`curl --aws-sigv4 "aws:amz:eu-west-1:execute-api" -u AKID:SECRET https://example.com/`,
+			`curl https://example.com/ --aws-sigv4 aws:amz:eu-west-1:execute-api -u AKID:SECRET`,
+		},
+	}
+
+	for _, test := range tests {
+		builder, err := FromCurl(test.command)
+
+		assert.Nil(t, err, "Should be nil")
+
+		req, err := builder.Build()
+
+		assert.Nil(t, err, "Should be nil")
+		assert.Equal(t, test.expected, req.AsCurl(), "Should equal curl command of "+test.command)
+
+		// The exported command gives back the same request.
+		builder, err = FromCurl(test.expected)
+
+		assert.Nil(t, err, "Should be nil")
+
+		req, err = builder.Build()
+
+		assert.Nil(t, err, "Should be nil")
+		assert.Equal(t, test.expected, req.AsCurl(), "Should round trip "+test.expected)
+	}
+}
+
+func TestFromCurlForm(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gorequest")
+
+	assert.Nil(t, err, "Should be nil")
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "report.txt")
+
+	assert.Nil(t, ioutil.WriteFile(path, []byte("Quarterly report"), 0600), "Should be nil")
+
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if err := req.ParseMultipartForm(1 << 20); err != nil {
+			resp.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		file, header, err := req.FormFile("report")
+
+		if err != nil {
+			resp.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		data, _ := ioutil.ReadAll(file)
+
+		resp.Write([]byte(req.Method + " " + req.FormValue("description") + " " + header.Filename + " " + header.Header.Get("Content-Type") + " " + string(data)))
+	}))
+	defer ts.Close()
+
+	builder, err := FromCurl(`curl ` + ts.URL + ` -F description=Report -F "report=@` + path + `;type=text/plain;filename=q1.txt" -m 5`)
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, 5*time.Second, builder.(*requestBuilder).timeout, "Should equal timeout")
+
+	req, err := builder.Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r, err := req.Do()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, "POST Report q1.txt text/plain Quarterly report", r.Text(), "Should have sent the multipart form")
+}
+
+func TestFromCurlErrors(t *testing.T) {
+	commands := []string{
+		`curl`,
+		`curl https://example.com --unknown`,
+		`curl https://example.com -H`,
+		`curl 'https://example.com`,
+		`curl https://a.example.com https://b.example.com`,
+		`curl https://example.com -d a=b -F c=d`,
+		`curl https://example.com --data-binary @-`,
+		`curl https://example.com -b cookies.txt`,
+		`curl https://example.com --aws-sigv4 aws:amz -u AKID:SECRET`,
+	}
+
+	for _, command := range commands {
+		_, err := FromCurl(command)
+
+		assert.NotNil(t, err, "Should not be able to parse "+command)
+	}
+}
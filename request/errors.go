@@ -0,0 +1,147 @@
+package request
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// ValidationError is returned by Build when the builder is missing a required
+// option, or when one of its options holds a value that cannot be used to
+// build a request (e.g. a JSON body that cannot be serialized).
+type ValidationError struct {
+	Option string
+	Err    error
+}
+
+func (e *ValidationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// UrlError is returned by Build when the URL provided to the builder cannot be
+// parsed, or does not carry the expected RFC 1738 credentials.
+type UrlError struct {
+	Url string
+	Err error
+}
+
+func (e *UrlError) Error() string {
+	return fmt.Sprintf("invalid URL %q: %v", e.Url, e.Err)
+}
+
+func (e *UrlError) Unwrap() error {
+	return e.Err
+}
+
+// TransportError is returned by Do when the HTTP client fails to send the
+// request or to receive a response (DNS, dial, TLS, timeout, ...).
+type TransportError struct {
+	Method string
+	Url    string
+	Err    error
+}
+
+func (e *TransportError) Error() string {
+	return fmt.Sprintf("%s %s: %v", e.Method, e.Url, e.Err)
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// ContextError is returned by Do and DoContext when the request's context is
+// cancelled or its deadline expires before the response body has been read.
+// It unwraps to context.Canceled or context.DeadlineExceeded.
+type ContextError struct {
+	Method string
+	Url    string
+	Err    error
+}
+
+func (e *ContextError) Error() string {
+	return fmt.Sprintf("%s %s: %v", e.Method, e.Url, e.Err)
+}
+
+func (e *ContextError) Unwrap() error {
+	return e.Err
+}
+
+// BodyReadError is returned by Do when a response was received but its body
+// could not be read. The underlying response (with headers and status) is
+// still available through Response.
+type BodyReadError struct {
+	Response *http.Response
+	Err      error
+}
+
+func (e *BodyReadError) Error() string {
+	return fmt.Sprintf("reading response body: %v", e.Err)
+}
+
+func (e *BodyReadError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeError is returned by Do when the response body could not be decoded
+// into the value set with WithResult or WithErrorResult. The response itself
+// is still available through Response.
+type DecodeError struct {
+	Response Response
+	Err      error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("decoding response body: %v", e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// ContentTypeError is returned by Response.Decode when the Content-Type of the
+// response is neither JSON nor XML, or is missing. Do leaves such bodies
+// undecoded instead (see WithErrorResult).
+type ContentTypeError struct {
+	ContentType string
+}
+
+func (e *ContentTypeError) Error() string {
+	return fmt.Sprintf("cannot decode content type %q", e.ContentType)
+}
+
+// OAuth2Error is returned by Do when an OAuth 2.0 authorization method could
+// not obtain a token. Code and Description come from the token endpoint's error
+// response (RFC 6749, section 5.2), when it sent one.
+type OAuth2Error struct {
+	StatusCode  int    `json:"-"`
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+	Uri         string `json:"error_uri"`
+}
+
+func (e *OAuth2Error) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("oauth2: %s (%d): %s", e.Code, e.StatusCode, e.Description)
+	}
+
+	return fmt.Sprintf("oauth2: %s (%d)", e.Code, e.StatusCode)
+}
+
+// MessageSignatureError is returned when an HTTP message signature (RFC 9421)
+// cannot be created, e.g. because a covered header is missing, or when a
+// MessageVerifier rejects the signature with the given label.
+type MessageSignatureError struct {
+	Label string
+	Err   error
+}
+
+func (e *MessageSignatureError) Error() string {
+	return fmt.Sprintf("message signature %q: %v", e.Label, e.Err)
+}
+
+func (e *MessageSignatureError) Unwrap() error {
+	return e.Err
+}
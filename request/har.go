@@ -0,0 +1,606 @@
+package request
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// REMARKS: Records the traffic of a transport in the HTTP Archive format (HAR 1.2). It sits in front of the
+// transport, so every exchange is recorded as sent: each redirect and each attempt (retries, authorization
+// challenges) is an entry of its own, with the headers added by the authorization methods. An entry is complete once
+// the response body has been read to the end or closed. Headers, cookies, bodies and the query of the URL are
+// redacted like the logs (see logPolicy), and bodies are truncated to the max body size.
+type harRecorder struct {
+	mutex   sync.Mutex
+	entries []*harEntry
+	policy  *logPolicy
+	now     func() time.Time
+}
+
+// REMARKS: Transport-level RoundTripper of a harRecorder.
+type harTransport struct {
+	recorder *harRecorder
+	next     http.RoundTripper
+}
+
+// REMARKS: Records the response body as it is read, and completes the entry at the end of it.
+type harBody struct {
+	io.ReadCloser
+	recorder *harRecorder
+	entry    *harEntry
+	timer    *harTimer
+	data     []byte
+	size     int64
+	once     sync.Once
+}
+
+// REMARKS: The events of httptrace, which may be reported from other goroutines (e.g. DNS lookups).
+type harTimer struct {
+	mutex        sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	gotConn      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+}
+
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string      `json:"version"`
+	Creator harCreator  `json:"creator"`
+	Entries []*harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// REMARKS: Fields starting with an underscore are custom fields, as allowed by the specification.
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Error           string      `json:"_error,omitempty"`
+	started         time.Time
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	Url         string         `json:"url"`
+	HttpVersion string         `json:"httpVersion"`
+	Cookies     []harCookie    `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HttpVersion string         `json:"httpVersion"`
+	Cookies     []harCookie    `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectUrl string         `json:"redirectURL"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harCookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Path     string `json:"path,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	Expires  string `json:"expires,omitempty"`
+	HttpOnly bool   `json:"httpOnly,omitempty"`
+	Secure   bool   `json:"secure,omitempty"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// REMARKS: HAR 1.2 has no encoding for request bodies; binary bodies are base64 encoded, which _encoding records.
+// REMARKS: _truncated marks a body that was recorded in part (or left out); it can't be replayed.
+type harPostData struct {
+	MimeType  string     `json:"mimeType"`
+	Params    []harParam `json:"params,omitempty"`
+	Text      string     `json:"text"`
+	Encoding  string     `json:"_encoding,omitempty"`
+	Truncated bool       `json:"_truncated,omitempty"`
+}
+
+type harParam struct {
+	Name        string `json:"name"`
+	Value       string `json:"value,omitempty"`
+	FileName    string `json:"fileName,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+}
+
+type harContent struct {
+	Size      int64  `json:"size"`
+	MimeType  string `json:"mimeType"`
+	Text      string `json:"text,omitempty"`
+	Encoding  string `json:"encoding,omitempty"`
+	Truncated bool   `json:"_truncated,omitempty"`
+}
+
+// REMARKS: In milliseconds; -1 when the phase did not happen (e.g. no DNS lookup on a reused connection).
+type harTimings struct {
+	Blocked float64 `json:"blocked"`
+	Dns     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	Ssl     float64 `json:"ssl"`
+}
+
+const harVersion = "1.2"
+const harCreatorName = "gorequest"
+
+func newHarRecorder() *harRecorder {
+	policy := newLogPolicy()
+	policy.maxBodySize = defaultHarMaxBodySize
+
+	return &harRecorder{
+		policy: policy,
+		now:    time.Now,
+	}
+}
+
+// REMARKS: Bodies are truncated to size bytes; 0 leaves them out.
+func (r *harRecorder) WithMaxBodySize(size int) HarRecorder {
+	r.policy.maxBodySize = size
+
+	return r
+}
+
+// REMARKS: See logPolicy.WithRedactedHeaders.
+func (r *harRecorder) WithRedactedHeaders(names ...string) HarRecorder {
+	r.policy.WithRedactedHeaders(names...)
+
+	return r
+}
+
+// REMARKS: See logPolicy.WithRedactedFields.
+func (r *harRecorder) WithRedactedFields(names ...string) HarRecorder {
+	r.policy.WithRedactedFields(names...)
+
+	return r
+}
+
+// REMARKS: A nil next is http.DefaultTransport.
+func (r *harRecorder) RoundTripper(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &harTransport{recorder: r, next: next}
+}
+
+func (r *harRecorder) Len() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return len(r.entries)
+}
+
+func (r *harRecorder) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.entries = nil
+}
+
+// REMARKS: The entries are sorted by start time. Like cookies, the file is only readable by its owner.
+func (r *harRecorder) Save(path string) error {
+	data, err := r.marshal()
+
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+func (r *harRecorder) WriteTo(w io.Writer) (int64, error) {
+	data, err := r.marshal()
+
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := w.Write(data)
+
+	return int64(n), err
+}
+
+func (t *harTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	r := t.recorder
+	timer := newHarTimer(r.now())
+	entry := r.newEntry(request, timer.start)
+
+	resp, err := t.next.RoundTrip(request.WithContext(httptrace.WithClientTrace(request.Context(), timer.trace(r.now))))
+
+	if err != nil {
+		// REMARKS: Recorded as a response with status 0, as browsers do.
+		entry.Error = err.Error()
+		entry.Response = harResponse{HttpVersion: "HTTP/1.1", Cookies: []harCookie{}, Headers: []harNameValue{}, HeadersSize: -1, BodySize: -1}
+		r.complete(entry, timer, r.now())
+
+		return resp, err
+	}
+
+	timer.headers(r.now())
+	r.setResponse(entry, resp)
+
+	resp.Body = &harBody{ReadCloser: resp.Body, recorder: r, entry: entry, timer: timer}
+
+	return resp, nil
+}
+
+func (b *harBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += int64(n)
+
+	if keep := b.recorder.policy.maxBodySize + 1 - len(b.data); keep > 0 {
+		if keep > n {
+			keep = n
+		}
+
+		b.data = append(b.data, p[:keep]...)
+	}
+
+	if err != nil {
+		b.done()
+	}
+
+	return n, err
+}
+
+func (b *harBody) Close() error {
+	b.done()
+
+	return b.ReadCloser.Close()
+}
+
+// ***********************************************
+// ********** Private methods/functions **********
+// ***********************************************
+
+func (r *harRecorder) newEntry(request *http.Request, start time.Time) *harEntry {
+	redactedUrl := r.policy.redactUrl(request.URL)
+
+	entry := &harEntry{
+		StartedDateTime: start.Format(time.RFC3339Nano),
+		started:         start,
+		Request: harRequest{
+			Method:      request.Method,
+			Url:         redactedUrl,
+			HttpVersion: request.Proto,
+			Cookies:     r.requestCookies(request),
+			Headers:     harHeaders(r.policy.redactHeader(request.Header)),
+			QueryString: []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    request.ContentLength,
+		},
+	}
+
+	if entry.Request.HttpVersion == "" {
+		entry.Request.HttpVersion = "HTTP/1.1"
+	}
+
+	if u, err := url.Parse(redactedUrl); err == nil {
+		for _, pair := range splitQuery(u.RawQuery) {
+			entry.Request.QueryString = append(entry.Request.QueryString, pair)
+		}
+	}
+
+	// REMARKS: Bodies that can only be read once are not recorded, and are marked as truncated so that they are not
+	// replayed as empty bodies. Their length is often unknown, which a ContentLength of 0 means for a client request.
+	if request.Body != nil && request.Body != http.NoBody {
+		contentType := request.Header.Get("Content-Type")
+		entry.Request.PostData = &harPostData{MimeType: contentType, Truncated: true}
+
+		if request.GetBody != nil {
+			if body, err := request.GetBody(); err == nil {
+				data, _ := readPrefix(body, r.policy.maxBodySize)
+				body.Close()
+
+				entry.Request.PostData.Text, entry.Request.PostData.Encoding, entry.Request.PostData.Truncated = r.bodyText(data, contentType)
+			}
+		}
+	}
+
+	return entry
+}
+
+func (r *harRecorder) setResponse(entry *harEntry, resp *http.Response) {
+	entry.Response = harResponse{
+		Status:      resp.StatusCode,
+		StatusText:  http.StatusText(resp.StatusCode),
+		HttpVersion: resp.Proto,
+		Cookies:     r.responseCookies(resp),
+		Headers:     harHeaders(r.policy.redactHeader(resp.Header)),
+		Content:     harContent{MimeType: resp.Header.Get("Content-Type")},
+		RedirectUrl: resp.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    -1,
+	}
+
+	if entry.Response.HttpVersion == "" {
+		entry.Response.HttpVersion = "HTTP/1.1"
+	}
+}
+
+func (r *harRecorder) complete(entry *harEntry, timer *harTimer, end time.Time) {
+	entry.Timings = timer.timings(end)
+
+	for _, t := range []float64{entry.Timings.Blocked, entry.Timings.Dns, entry.Timings.Connect, entry.Timings.Send, entry.Timings.Wait, entry.Timings.Receive} {
+		if t > 0 {
+			entry.Time += t
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.entries = append(r.entries, entry)
+}
+
+func (b *harBody) done() {
+	b.once.Do(func() {
+		b.entry.Response.Content.Size = b.size
+		b.entry.Response.BodySize = b.size
+		b.entry.Response.Content.Text, b.entry.Response.Content.Encoding, b.entry.Response.Content.Truncated = b.recorder.bodyText(b.data, b.entry.Response.Content.MimeType)
+		b.recorder.complete(b.entry, b.timer, b.recorder.now())
+	})
+}
+
+// REMARKS: Text bodies are redacted and truncated; binary bodies are truncated and base64 encoded. Also tells
+// whether the body was truncated (or left out).
+func (r *harRecorder) bodyText(data []byte, contentType string) (string, string, bool) {
+	truncated := len(data) > r.policy.maxBodySize
+
+	if r.policy.maxBodySize <= 0 || len(data) == 0 {
+		return "", "", truncated
+	}
+
+	if !utf8.Valid(data) {
+		if truncated {
+			data = data[:r.policy.maxBodySize]
+		}
+
+		return base64.StdEncoding.EncodeToString(data), "base64", truncated
+	}
+
+	return r.policy.redactBody(data, contentType), "", truncated
+}
+
+func (r *harRecorder) requestCookies(request *http.Request) []harCookie {
+	cookies := []harCookie{}
+
+	for _, cookie := range request.Cookies() {
+		value := cookie.Value
+
+		if r.policy.redactedHeaders["Cookie"] {
+			value = redactedValue
+		}
+
+		cookies = append(cookies, harCookie{Name: cookie.Name, Value: value})
+	}
+
+	return cookies
+}
+
+func (r *harRecorder) responseCookies(resp *http.Response) []harCookie {
+	cookies := []harCookie{}
+
+	for _, cookie := range resp.Cookies() {
+		c := harCookie{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Path:     cookie.Path,
+			Domain:   cookie.Domain,
+			HttpOnly: cookie.HttpOnly,
+			Secure:   cookie.Secure,
+		}
+
+		if r.policy.redactedHeaders["Set-Cookie"] {
+			c.Value = redactedValue
+		}
+
+		if !cookie.Expires.IsZero() {
+			c.Expires = cookie.Expires.UTC().Format(time.RFC3339)
+		}
+
+		cookies = append(cookies, c)
+	}
+
+	return cookies
+}
+
+func (r *harRecorder) marshal() ([]byte, error) {
+	r.mutex.Lock()
+	entries := append([]*harEntry{}, r.entries...)
+	r.mutex.Unlock()
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].started.Before(entries[j].started)
+	})
+
+	return json.MarshalIndent(&harFile{
+		Log: harLog{
+			Version: harVersion,
+			Creator: harCreator{Name: harCreatorName, Version: harVersion},
+			Entries: entries,
+		},
+	}, "", "  ")
+}
+
+func newHarTimer(start time.Time) *harTimer {
+	return &harTimer{start: start}
+}
+
+func (t *harTimer) trace(now func() time.Time) *httptrace.ClientTrace {
+	set := func(field *time.Time) {
+		t.mutex.Lock()
+		defer t.mutex.Unlock()
+
+		*field = now()
+	}
+
+	return &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { set(&t.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { set(&t.dnsDone) },
+		ConnectStart:         func(string, string) { set(&t.connectStart) },
+		ConnectDone:          func(string, string, error) { set(&t.connectDone) },
+		TLSHandshakeStart:    func() { set(&t.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { set(&t.tlsDone) },
+		GotConn:              func(httptrace.GotConnInfo) { set(&t.gotConn) },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { set(&t.wroteRequest) },
+		GotFirstResponseByte: func() { set(&t.firstByte) },
+	}
+}
+
+// REMARKS: The time the response headers were received, for transports that don't report the first byte.
+func (t *harTimer) headers(at time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.firstByte.IsZero() {
+		t.firstByte = at
+	}
+}
+
+// REMARKS: Ref: http://www.softwareishard.com/blog/har-12-spec/#timings; ssl is part of connect.
+func (t *harTimer) timings(end time.Time) harTimings {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	timings := harTimings{
+		Dns:     harDuration(t.dnsStart, t.dnsDone),
+		Connect: harDuration(t.connectStart, t.connectDone),
+		Ssl:     -1,
+	}
+
+	if !t.tlsDone.IsZero() {
+		timings.Ssl = harDuration(t.tlsStart, t.tlsDone)
+		timings.Connect = harDuration(t.connectStart, t.tlsDone)
+	}
+
+	gotConn, wroteRequest, firstByte := t.gotConn, t.wroteRequest, t.firstByte
+
+	if gotConn.IsZero() {
+		gotConn = t.start
+	}
+
+	if wroteRequest.IsZero() {
+		wroteRequest = gotConn
+	}
+
+	if firstByte.IsZero() {
+		firstByte = end
+	}
+
+	timings.Blocked = milliseconds(gotConn.Sub(t.start))
+
+	for _, phase := range []float64{timings.Dns, timings.Connect} {
+		if phase > 0 {
+			timings.Blocked -= phase
+		}
+	}
+
+	if timings.Blocked < 0 {
+		timings.Blocked = 0
+	}
+
+	timings.Send = milliseconds(wroteRequest.Sub(gotConn))
+	timings.Wait = milliseconds(firstByte.Sub(wroteRequest))
+	timings.Receive = milliseconds(end.Sub(firstByte))
+
+	return timings
+}
+
+func harDuration(start, end time.Time) float64 {
+	if start.IsZero() || end.IsZero() {
+		return -1
+	}
+
+	return milliseconds(end.Sub(start))
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// REMARKS: Sorted by name, for stable archives.
+func harHeaders(header http.Header) []harNameValue {
+	names := make([]string, 0, len(header))
+
+	for name := range header {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	pairs := []harNameValue{}
+
+	for _, name := range names {
+		for _, value := range header[name] {
+			pairs = append(pairs, harNameValue{Name: name, Value: value})
+		}
+	}
+
+	return pairs
+}
+
+// REMARKS: Keeps the order of the parameters, which url.Values would lose.
+func splitQuery(query string) []harNameValue {
+	var pairs []harNameValue
+
+	for _, pair := range strings.Split(query, "&") {
+		if pair == "" {
+			continue
+		}
+
+		parts := strings.SplitN(pair, "=", 2)
+		name, _ := url.QueryUnescape(parts[0])
+		value := ""
+
+		if len(parts) == 2 {
+			value, _ = url.QueryUnescape(parts[1])
+		}
+
+		pairs = append(pairs, harNameValue{Name: name, Value: value})
+	}
+
+	return pairs
+}
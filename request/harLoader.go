@@ -0,0 +1,173 @@
+package request
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// REMARKS: Headers that are not replayed as recorded: HTTP/2 pseudo-headers (":authority", as recorded by browsers)
+// are skipped by prefix, the others are set by the transport for the request being sent. Accept-Encoding in
+// particular would turn off the transparent decompression of the response.
+var harSkippedHeaders = map[string]bool{
+	"Host":              true,
+	"Content-Length":    true,
+	"Connection":        true,
+	"Accept-Encoding":   true,
+	"Transfer-Encoding": true,
+}
+
+// REMARKS: Turns the entries of a HAR file (recorded by a HarRecorder or exported by a browser) into builders,
+// in the order of the file. Redacted values are replayed as they were recorded ("[REDACTED]"), so fill them in
+// (e.g. WithHeader on the builders) before sending the requests. Entries whose request body was truncated by the
+// recorder are an error.
+func loadHar(path string) ([]RequestBuilder, error) {
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return parseHar(data)
+}
+
+func parseHar(data []byte) ([]RequestBuilder, error) {
+	var file harFile
+
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("Invalid HAR file: %v.", err)
+	}
+
+	builders := make([]RequestBuilder, 0, len(file.Log.Entries))
+
+	for i, entry := range file.Log.Entries {
+		builder, err := entry.Request.builder()
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid HAR entry %d. %v", i, err)
+		}
+
+		builders = append(builders, builder)
+	}
+
+	return builders, nil
+}
+
+// ***********************************************
+// ********** Private methods/functions **********
+// ***********************************************
+
+func (r *harRequest) builder() (RequestBuilder, error) {
+	if r.Url == "" {
+		return nil, errors.New("The request has no URL.")
+	}
+
+	b := NewRequestBuilder().WithMethod(r.Method).WithUrl(r.Url)
+
+	// REMARKS: The builder keeps one value per header; repeated headers are joined as a list.
+	header := make(http.Header)
+
+	for _, pair := range r.Headers {
+		if strings.HasPrefix(pair.Name, ":") || harSkippedHeaders[http.CanonicalHeaderKey(pair.Name)] {
+			continue
+		}
+
+		header.Add(pair.Name, pair.Value)
+	}
+
+	// REMARKS: A multipart body sets its own Content-Type, with a new boundary.
+	multipart := r.PostData != nil && r.PostData.isMultipart(header.Get("Content-Type"))
+
+	if multipart {
+		header.Del("Content-Type")
+	}
+
+	for name, values := range header {
+		separator := ", "
+
+		if name == "Cookie" {
+			separator = "; "
+		}
+
+		b.WithHeader(name, strings.Join(values, separator))
+	}
+
+	if r.PostData == nil {
+		return b, nil
+	}
+
+	if r.PostData.Truncated {
+		return nil, errors.New("The request body was truncated when it was recorded.")
+	}
+
+	switch {
+	case multipart:
+		r.PostData.setMultipart(b)
+
+		return b, nil
+	case header.Get("Content-Type") == "" && r.PostData.MimeType != "":
+		b.WithHeader("Content-Type", r.PostData.MimeType)
+	}
+
+	switch {
+	case r.PostData.Encoding == "base64":
+		data, err := base64.StdEncoding.DecodeString(r.PostData.Text)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid base64 request body: %v.", err)
+		}
+
+		b.WithTextBody(string(data))
+	case r.PostData.Text != "":
+		b.WithTextBody(r.PostData.Text)
+	case len(r.PostData.Params) > 0:
+		values := make(url.Values)
+
+		for _, param := range r.PostData.Params {
+			values.Add(param.Name, param.Value)
+		}
+
+		b.WithFormBody(values)
+	}
+
+	return b, nil
+}
+
+// REMARKS: Browsers may record forms as params only, without the text of the body.
+func (p *harPostData) isMultipart(contentType string) bool {
+	if contentType == "" {
+		contentType = p.MimeType
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	return p.Text == "" && len(p.Params) > 0 && mediaType == "multipart/form-data"
+}
+
+// REMARKS: Files are replayed with the content that was recorded, if any.
+func (p *harPostData) setMultipart(b RequestBuilder) {
+	form := NewMultipartBuilder()
+
+	for _, param := range p.Params {
+		if param.FileName == "" {
+			form.WithField(param.Name, param.Value)
+			continue
+		}
+
+		partType := param.ContentType
+
+		if partType == "" {
+			partType = defaultPartContentType
+		}
+
+		form.WithReader(param.Name, param.FileName, partType, strings.NewReader(param.Value))
+	}
+
+	b.WithMultipartBody(form)
+}
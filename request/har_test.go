@@ -0,0 +1,284 @@
+package request
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newHarServer() *httptest.Server {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/login", func(resp http.ResponseWriter, req *http.Request) {
+		http.SetCookie(resp, &http.Cookie{Name: "session", Value: "abc123", Path: "/", HttpOnly: true})
+		http.Redirect(resp, req, "/home", http.StatusFound)
+	})
+
+	mux.HandleFunc("/home", func(resp http.ResponseWriter, req *http.Request) {
+		resp.Header().Set("Content-Type", "application/json")
+		resp.Write([]byte(`{"user":"john","access_token":"secret","bio":"` + strings.Repeat("x", 100) + `"}`))
+	})
+
+	mux.HandleFunc("/echo", func(resp http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+
+		resp.Header().Set("Content-Type", "text/plain")
+		resp.Write([]byte(req.Method + " " + req.URL.RawQuery + " " + req.Header.Get("X-Custom") + " " + req.Header.Get("Content-Type") + " " + string(body)))
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func readHar(t *testing.T, recorder HarRecorder) *harFile {
+	var b bytes.Buffer
+
+	_, err := recorder.WriteTo(&b)
+
+	assert.Nil(t, err, "Should be nil")
+
+	var file harFile
+
+	assert.Nil(t, json.Unmarshal(b.Bytes(), &file), "Should be nil")
+
+	return &file
+}
+
+func TestHarRecorder(t *testing.T) {
+	ts := newHarServer()
+	defer ts.Close()
+
+	recorder := NewHarRecorder().WithMaxBodySize(64)
+	session := NewSession()
+	session.WithHarRecorder(recorder)
+
+	req, err := session.NewRequestBuilder().
+		WithMethod("POST").
+		WithUrl(ts.URL+"/login?client_secret=secret&next=home").
+		WithFormBody(map[string][]string{"user": {"john"}, "password": {"hunter2"}}).
+		WithBasicAuth("john", "hunter2").
+		Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r, err := req.Do()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.True(t, strings.HasSuffix(r.Text(), strings.Repeat("x", 100)+`"}`), "Should have left the whole body to the caller")
+	assert.Equal(t, 2, recorder.Len(), "Should have recorded the redirect")
+
+	file := readHar(t, recorder)
+
+	assert.Equal(t, "1.2", file.Log.Version, "Should equal HAR version")
+	assert.Equal(t, "gorequest", file.Log.Creator.Name, "Should equal creator")
+
+	login, home := file.Log.Entries[0], file.Log.Entries[1]
+
+	assert.Equal(t, "POST", login.Request.Method, "Should equal method")
+	assert.Equal(t, ts.URL+"/login?client_secret=[REDACTED]&next=home", login.Request.Url, "Should have redacted the query")
+	assert.Equal(t, []harNameValue{{Name: "client_secret", Value: "[REDACTED]"}, {Name: "next", Value: "home"}}, login.Request.QueryString, "Should equal query string")
+	assert.Contains(t, login.Request.Headers, harNameValue{Name: "Authorization", Value: "Basic [REDACTED]"}, "Should have redacted the authorization")
+	assert.Equal(t, "password=[REDACTED]&user=john", login.Request.PostData.Text, "Should have redacted the form")
+	assert.Equal(t, "application/x-www-form-urlencoded", login.Request.PostData.MimeType, "Should equal mime type")
+	assert.Equal(t, http.StatusFound, login.Response.Status, "Should equal HTTP Status 302 (Found)")
+	assert.Equal(t, "/home", login.Response.RedirectUrl, "Should equal redirect URL")
+	assert.Equal(t, []harCookie{{Name: "session", Value: "[REDACTED]", Path: "/", HttpOnly: true}}, login.Response.Cookies, "Should have recorded the cookie")
+
+	assert.Equal(t, "GET", home.Request.Method, "Should have followed the redirect")
+	assert.Equal(t, []harCookie{{Name: "session", Value: "[REDACTED]"}}, home.Request.Cookies, "Should have recorded the cookie sent")
+	assert.Equal(t, int64(148), home.Response.Content.Size, "Should equal content size")
+	assert.Equal(t, "application/json", home.Response.Content.MimeType, "Should equal mime type")
+	assert.Equal(t, `{"user":"john","access_token":"[REDACTED]","bio":"xxxxxxxxxxxxxxxxxx...(truncated)`, home.Response.Content.Text, "Should have redacted and truncated the body")
+	assert.True(t, home.Time >= 0, "Should have timings")
+	assert.True(t, home.Timings.Wait >= 0, "Should have a wait time")
+	assert.True(t, login.Timings.Connect >= 0, "Should have opened a connection")
+	assert.NotEmpty(t, home.StartedDateTime, "Should have a start time")
+
+	recorder.Reset()
+
+	assert.Equal(t, 0, recorder.Len(), "Should have removed the entries")
+}
+
+func TestHarRecorderError(t *testing.T) {
+	recorder := NewHarRecorder()
+
+	req, err := NewRequestBuilder().WithUrl("http://127.0.0.1:1/").WithHarRecorder(recorder).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	_, err = req.Do()
+
+	assert.NotNil(t, err, "Should not be nil")
+
+	file := readHar(t, recorder)
+
+	assert.Equal(t, 1, len(file.Log.Entries), "Should have recorded the failed request")
+	assert.Equal(t, 0, file.Log.Entries[0].Response.Status, "Should equal status 0")
+	assert.NotEmpty(t, file.Log.Entries[0].Error, "Should have recorded the error")
+}
+
+func TestHarReplay(t *testing.T) {
+	ts := newHarServer()
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "gorequest")
+
+	assert.Nil(t, err, "Should be nil")
+
+	defer os.RemoveAll(dir)
+
+	recorder := NewHarRecorder()
+	client := NewClient().WithHarRecorder(recorder)
+
+	builders := []RequestBuilder{
+		client.NewRequestBuilder().WithMethod("PUT").WithUrl(ts.URL+"/echo?a=1").WithHeader("X-Custom", "custom").WithJsonBody(`{"name":"john"}`),
+		client.NewRequestBuilder().WithMethod("POST").WithUrl(ts.URL+"/echo").WithBodyReader(bytes.NewReader([]byte{0xff, 0xfe}), "application/octet-stream"),
+	}
+
+	var expected []string
+
+	for _, builder := range builders {
+		req, err := builder.Build()
+
+		assert.Nil(t, err, "Should be nil")
+
+		r, err := req.Do()
+
+		assert.Nil(t, err, "Should be nil")
+
+		expected = append(expected, r.Text())
+	}
+
+	path := filepath.Join(dir, "traffic.har")
+
+	assert.Nil(t, recorder.Save(path), "Should be nil")
+
+	loaded, err := LoadHar(path)
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, 2, len(loaded), "Should have loaded every entry")
+
+	for i, builder := range loaded {
+		req, err := builder.Build()
+
+		assert.Nil(t, err, "Should be nil")
+
+		r, err := req.Do()
+
+		assert.Nil(t, err, "Should be nil")
+		assert.Equal(t, expected[i], r.Text(), "Should have replayed the request")
+	}
+}
+
+func TestHarLoadTruncatedEntries(t *testing.T) {
+	ts := newHarServer()
+	defer ts.Close()
+
+	recorder := NewHarRecorder().WithMaxBodySize(8)
+	client := NewClient().WithHarRecorder(recorder)
+
+	for _, builder := range []RequestBuilder{
+		client.NewRequestBuilder().WithMethod("POST").WithUrl(ts.URL + "/echo").WithTextBody("short"),
+		client.NewRequestBuilder().WithMethod("POST").WithUrl(ts.URL + "/echo").WithTextBody("a body longer than 8 bytes"),
+		client.NewRequestBuilder().WithMethod("POST").WithUrl(ts.URL+"/echo").WithBodyReader(ioutil.NopCloser(strings.NewReader("once")), "application/octet-stream"),
+	} {
+		req, err := builder.Build()
+
+		assert.Nil(t, err, "Should be nil")
+
+		_, err = req.Do()
+
+		assert.Nil(t, err, "Should be nil")
+	}
+
+	file := readHar(t, recorder)
+
+	assert.False(t, file.Log.Entries[0].Request.PostData.Truncated, "Should not have marked the short body")
+	assert.True(t, file.Log.Entries[1].Request.PostData.Truncated, "Should have marked the truncated body")
+	assert.True(t, file.Log.Entries[1].Response.Content.Truncated, "Should have marked the truncated response")
+
+	data, err := json.Marshal(file)
+
+	assert.Nil(t, err, "Should be nil")
+	assert.True(t, strings.Contains(string(data), `"_truncated":true`), "Should have recorded a custom field")
+
+	_, err = parseHar(data)
+
+	assert.NotNil(t, err, "Should not be able to load an entry whose body was truncated")
+
+	// A body that can only be read once is not recorded at all.
+	assert.True(t, file.Log.Entries[2].Request.PostData.Truncated, "Should have marked the body that was not recorded")
+
+	file.Log.Entries = file.Log.Entries[2:]
+	data, err = json.Marshal(file)
+
+	assert.Nil(t, err, "Should be nil")
+
+	_, err = parseHar(data)
+
+	assert.NotNil(t, err, "Should not be able to load an entry whose body was not recorded")
+}
+
+func TestHarLoadBrowserExport(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if err := req.ParseMultipartForm(1 << 20); err != nil {
+			resp.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		file, header, err := req.FormFile("report")
+
+		if err != nil {
+			resp.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		data, _ := ioutil.ReadAll(file)
+
+		resp.Write([]byte(req.FormValue("description") + " " + header.Filename + " " + string(data) + " " + req.Header.Get("Accept-Encoding")))
+	}))
+	defer ts.Close()
+
+	har := `{"log": {"version": "1.2", "creator": {"name": "WebInspector", "version": "537.36"}, "entries": [{
+		"request": {
+			"method": "POST",
+			"url": "` + ts.URL + `/upload",
+			"headers": [
+				{"name": ":authority", "value": "example.com"},
+				{"name": "accept-encoding", "value": "gzip, deflate, br"},
+				{"name": "content-type", "value": "multipart/form-data; boundary=----WebKitFormBoundary"}
+			],
+			"postData": {
+				"mimeType": "multipart/form-data; boundary=----WebKitFormBoundary",
+				"params": [
+					{"name": "description", "value": "Report"},
+					{"name": "report", "fileName": "q1.txt", "contentType": "text/plain", "value": "Quarterly report"}
+				]
+			}
+		}
+	}]}}`
+
+	builders, err := parseHar([]byte(har))
+
+	assert.Nil(t, err, "Should be nil")
+
+	req, err := builders[0].Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r, err := req.Do()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, "Report q1.txt Quarterly report gzip", r.Text(), "Should have replayed the multipart form")
+
+	_, err = parseHar([]byte(`{"log": {"entries": [{"request": {"method": "GET"}}]}}`))
+
+	assert.NotNil(t, err, "Should not be able to load an entry without URL")
+}
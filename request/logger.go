@@ -0,0 +1,277 @@
+package request
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// REMARKS: Logs one record per attempt: "HTTP request" (Info) once the response headers are received, or
+// "HTTP request failed" (Error) with the error. Headers and bodies are only logged when the policy asks for them.
+type requestLogger struct {
+	logger Logger
+	policy *logPolicy
+	now    func() time.Time
+}
+
+type logPolicy struct {
+	headers         bool
+	maxBodySize     int
+	redactedHeaders map[string]bool
+	redactedFields  map[string]bool
+}
+
+const redactedValue = "[REDACTED]"
+
+func newRequestLogger(logger Logger, policy *logPolicy) *requestLogger {
+	return &requestLogger{
+		logger: logger,
+		policy: policy,
+		now:    time.Now,
+	}
+}
+
+func newLogPolicy() *logPolicy {
+	policy := &logPolicy{
+		redactedHeaders: make(map[string]bool),
+		redactedFields:  make(map[string]bool),
+	}
+
+	policy.WithRedactedHeaders(defaultRedactedHeaders...)
+	policy.WithRedactedFields(defaultRedactedFields...)
+
+	return policy
+}
+
+func (p *logPolicy) WithHeaders() LogPolicy {
+	p.headers = true
+
+	return p
+}
+
+// REMARKS: Bodies are logged up to maxSize bytes; larger bodies are truncated. Bodies that can only be read once
+// (WithBodyReader, WithMultipartBody) are not logged. A streamed response (DoStream) is read up to maxSize bytes
+// before it is returned, and the rest is left to the caller.
+func (p *logPolicy) WithBodies(maxSize int) LogPolicy {
+	p.maxBodySize = maxSize
+
+	return p
+}
+
+// REMARKS: Adds to the default headers (Authorization, Proxy-Authorization, Cookie and Set-Cookie). The scheme of
+// an authorization and the names of cookies are kept, e.g. "Bearer [REDACTED]".
+func (p *logPolicy) WithRedactedHeaders(names ...string) LogPolicy {
+	for _, name := range names {
+		p.redactedHeaders[http.CanonicalHeaderKey(name)] = true
+	}
+
+	return p
+}
+
+// REMARKS: Adds to the default fields (password, client_secret, access_token, refresh_token, id_token). The fields
+// are masked at any depth in JSON bodies, and in form bodies and the query of the URL.
+func (p *logPolicy) WithRedactedFields(names ...string) LogPolicy {
+	for _, name := range names {
+		p.redactedFields[name] = true
+	}
+
+	return p
+}
+
+func (l *requestLogger) Wrap(next RoundTripperFunc) RoundTripperFunc {
+	return func(request *http.Request) (*http.Response, error) {
+		args := []interface{}{"method", request.Method, "url", l.policy.redactUrl(request.URL)}
+
+		if l.policy.headers {
+			args = append(args, "requestHeaders", l.policy.redactHeader(request.Header))
+		}
+
+		if l.policy.maxBodySize > 0 && request.GetBody != nil {
+			if body, err := request.GetBody(); err == nil {
+				data, _ := readPrefix(body, l.policy.maxBodySize)
+				body.Close()
+
+				args = append(args, "requestBody", l.policy.redactBody(data, request.Header.Get("Content-Type")))
+			}
+		}
+
+		start := l.now()
+		resp, err := next(request)
+		latency := l.now().Sub(start)
+
+		if err != nil {
+			l.logger.Error("HTTP request failed", append(args, "latency", latency, "error", err)...)
+
+			return resp, err
+		}
+
+		args = append(args, "status", resp.StatusCode, "latency", latency)
+
+		if status := resp.Header.Get("Cache-Status"); status != "" {
+			args = append(args, "cache", status)
+		}
+
+		if l.policy.headers {
+			args = append(args, "responseHeaders", l.policy.redactHeader(resp.Header))
+		}
+
+		if l.policy.maxBodySize > 0 && resp.Body != nil {
+			data, readErr := readPrefix(resp.Body, l.policy.maxBodySize)
+
+			// REMARKS: The caller still reads the whole body, from what was read here followed by the rest.
+			resp.Body = &prefixedBody{Reader: io.MultiReader(bytes.NewReader(data), &errorReader{err: readErr}, resp.Body), Closer: resp.Body}
+
+			args = append(args, "responseBody", l.policy.redactBody(data, resp.Header.Get("Content-Type")))
+		}
+
+		l.logger.Info("HTTP request", args...)
+
+		return resp, nil
+	}
+}
+
+// ***********************************************
+// ********** Private methods/functions **********
+// ***********************************************
+
+func (p *logPolicy) redactHeader(header http.Header) http.Header {
+	redacted := header.Clone()
+
+	for name, values := range redacted {
+		if !p.redactedHeaders[name] {
+			continue
+		}
+
+		masked := make([]string, len(values))
+
+		for i, value := range values {
+			masked[i] = redactHeaderValue(name, value)
+		}
+
+		redacted[name] = masked
+	}
+
+	return redacted
+}
+
+// REMARKS: Also masks the password of the userinfo (see WithRFC1738).
+func (p *logPolicy) redactUrl(u *url.URL) string {
+	redacted := *u
+	redacted.RawQuery = p.redactForm(u.RawQuery)
+
+	if _, ok := u.User.Password(); ok {
+		redacted.User = url.UserPassword(u.User.Username(), redactedValue)
+	}
+
+	return redacted.String()
+}
+
+func (p *logPolicy) redactBody(data []byte, contentType string) string {
+	truncated := len(data) > p.maxBodySize
+
+	if truncated {
+		data = data[:p.maxBodySize]
+	}
+
+	body := string(data)
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		body = p.redactForm(body)
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		body = p.redactJson(body)
+	}
+
+	if truncated {
+		body += "...(truncated)"
+	}
+
+	return body
+}
+
+// REMARKS: Works on the text rather than on decoded values, so that truncated bodies are redacted too and the
+// rest of the body is logged exactly as it was sent.
+func (p *logPolicy) redactJson(body string) string {
+	return jsonFieldRegex.ReplaceAllStringFunc(body, func(field string) string {
+		match := jsonFieldRegex.FindStringSubmatch(field)
+
+		if !p.redactedFields[match[1]] {
+			return field
+		}
+
+		return match[0][:len(match[0])-len(match[2])] + `"` + redactedValue + `"`
+	})
+}
+
+// REMARKS: Values are replaced in place (unescaped, for readability), keeping the order and encoding of the other parameters.
+func (p *logPolicy) redactForm(form string) string {
+	if form == "" {
+		return form
+	}
+
+	pairs := strings.Split(form, "&")
+
+	for i, pair := range pairs {
+		key := strings.SplitN(pair, "=", 2)[0]
+
+		if name, err := url.QueryUnescape(key); err == nil && p.redactedFields[name] {
+			pairs[i] = key + "=" + redactedValue
+		}
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+// REMARKS: Matches `"name": value` where value is a string or a literal; objects and arrays are left alone.
+var jsonFieldRegex = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"\s*:\s*("(?:[^"\\]|\\.)*"?|[^\s,{}\[\]"]+)`)
+
+func redactHeaderValue(name, value string) string {
+	switch name {
+	case "Authorization", "Proxy-Authorization":
+		if i := strings.Index(value, " "); i > 0 {
+			return value[:i] + " " + redactedValue
+		}
+	case "Cookie":
+		cookies := strings.Split(value, ";")
+
+		for i, cookie := range cookies {
+			cookies[i] = strings.SplitN(strings.TrimSpace(cookie), "=", 2)[0] + "=" + redactedValue
+		}
+
+		return strings.Join(cookies, "; ")
+	case "Set-Cookie":
+		return strings.SplitN(value, "=", 2)[0] + "=" + redactedValue
+	}
+
+	return redactedValue
+}
+
+// REMARKS: Reads one byte more than maxSize, to tell whether the body has to be truncated.
+func readPrefix(reader io.Reader, maxSize int) ([]byte, error) {
+	return ioutil.ReadAll(io.LimitReader(reader, int64(maxSize)+1))
+}
+
+type prefixedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// REMARKS: Returns the error met while reading the logged prefix of a body, once the prefix has been read.
+type errorReader struct {
+	err error
+}
+
+func (r *errorReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	return 0, io.EOF
+}
@@ -0,0 +1,33 @@
+//go:build go1.21
+// +build go1.21
+
+package request
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoggerSlog(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		resp.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	var out bytes.Buffer
+
+	req, err := NewRequestBuilder().WithUrl(ts.URL).WithLogger(slog.New(slog.NewTextHandler(&out, nil))).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	_, err = req.Do()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.True(t, strings.Contains(out.String(), `msg="HTTP request" method=GET url=`+ts.URL+` status=200 latency=`), "Should have logged with slog: "+out.String())
+}
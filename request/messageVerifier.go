@@ -0,0 +1,338 @@
+package request
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// REMARKS: Server side of HTTP Message Signatures (RFC 9421). Every signature on the request must verify with the
+// key returned for its keyid: a []byte for hmac-sha256, an *rsa.PublicKey for rsa-pss-sha512, or an
+// ed25519.PublicKey (the matching private keys are accepted too). A covered Content-Digest is checked against the
+// body, which is read (up to maxBodySize bytes) and replaced so the handler can still read it.
+type messageVerifier struct {
+	keys        func(keyId string) (interface{}, error)
+	required    []string
+	maxAge      time.Duration
+	maxBodySize int
+	now         func() time.Time
+}
+
+func newMessageVerifier(keys func(keyId string) (interface{}, error)) *messageVerifier {
+	return &messageVerifier{
+		keys:        keys,
+		required:    defaultSignatureComponents,
+		maxBodySize: defaultVerifierMaxBodySize,
+		now:         time.Now,
+	}
+}
+
+// REMARKS: Rejects signatures that do not cover all of the given components. Defaults to "@method", "@authority"
+// and "@path", so that a signature can't be replayed against another request; WithRequiredComponents() accepts
+// signatures whatever they cover.
+func (v *messageVerifier) WithRequiredComponents(components ...string) MessageVerifier {
+	v.required = components
+
+	return v
+}
+
+// REMARKS: Rejects requests whose body is larger than size bytes when the signature covers their Content-Digest.
+func (v *messageVerifier) WithMaxBodySize(size int) MessageVerifier {
+	v.maxBodySize = size
+
+	return v
+}
+
+// REMARKS: Rejects signatures created longer ago than maxAge (signatures without a created parameter included).
+func (v *messageVerifier) WithMaxAge(maxAge time.Duration) MessageVerifier {
+	v.maxAge = maxAge
+
+	return v
+}
+
+func (v *messageVerifier) Verify(request *http.Request) error {
+	inputs := splitStructured(strings.Join(request.Header.Values("Signature-Input"), ","), ',')
+	signatures := make(map[string]string)
+
+	for _, member := range splitStructured(strings.Join(request.Header.Values("Signature"), ","), ',') {
+		if label, value, ok := splitMember(member); ok {
+			signatures[label] = value
+		}
+	}
+
+	if len(inputs) == 0 {
+		return &MessageSignatureError{Err: errors.New("The request is not signed.")}
+	}
+
+	for _, input := range inputs {
+		label, value, ok := splitMember(input)
+
+		if !ok {
+			return &MessageSignatureError{Err: fmt.Errorf("Invalid Signature-Input member %q.", input)}
+		}
+
+		if err := v.verify(request, value, signatures[label]); err != nil {
+			return &MessageSignatureError{Label: label, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// REMARKS: Responds 401 Unauthorized to requests that fail verification, without calling next.
+func (v *messageVerifier) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, request *http.Request) {
+		if err := v.Verify(request); err != nil {
+			http.Error(resp, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(resp, request)
+	})
+}
+
+// ***********************************************
+// ********** Private methods/functions **********
+// ***********************************************
+
+func (v *messageVerifier) verify(request *http.Request, input, signature string) error {
+	if len(signature) < 2 || signature[0] != ':' || signature[len(signature)-1] != ':' {
+		return errors.New("The signature is missing.")
+	}
+
+	signatureBytes, err := base64.StdEncoding.DecodeString(signature[1 : len(signature)-1])
+
+	if err != nil {
+		return err
+	}
+
+	components, params, err := parseSignatureInput(input)
+
+	if err != nil {
+		return err
+	}
+
+	key, err := v.keys(params["keyid"])
+
+	if err != nil {
+		return err
+	}
+
+	algorithm, err := algorithmForKey(key)
+
+	if err != nil {
+		return err
+	}
+
+	if alg, ok := params["alg"]; ok && alg != algorithm.name() {
+		return fmt.Errorf("The algorithm %q does not match the key.", alg)
+	}
+
+	covered := make(map[string]bool)
+
+	for _, component := range components {
+		covered[component.name] = true
+	}
+
+	for _, name := range v.required {
+		if !covered[strings.ToLower(name)] {
+			return fmt.Errorf("The required component %q is not covered.", name)
+		}
+	}
+
+	now := v.now().Unix()
+
+	if expires, ok := params["expires"]; ok {
+		if t, err := strconv.ParseInt(expires, 10, 64); err != nil || now > t {
+			return errors.New("The signature has expired.")
+		}
+	}
+
+	if v.maxAge > 0 {
+		if t, err := strconv.ParseInt(params["created"], 10, 64); err != nil || now-t > int64(v.maxAge/time.Second) {
+			return errors.New("The signature is too old.")
+		}
+	}
+
+	if covered["content-digest"] {
+		if err := verifyContentDigest(request, v.maxBodySize); err != nil {
+			return err
+		}
+	}
+
+	base, err := signatureBase(request, components, input)
+
+	if err != nil {
+		return err
+	}
+
+	return algorithm.verify([]byte(base), signatureBytes)
+}
+
+func algorithmForKey(key interface{}) (signatureAlgorithm, error) {
+	switch k := key.(type) {
+	case []byte:
+		return &hmacSha256Algorithm{key: k}, nil
+	case *rsa.PublicKey:
+		return &rsaPssAlgorithm{publicKey: k}, nil
+	case *rsa.PrivateKey:
+		return &rsaPssAlgorithm{privateKey: k, publicKey: &k.PublicKey}, nil
+	case ed25519.PublicKey:
+		return &ed25519Algorithm{publicKey: k}, nil
+	case ed25519.PrivateKey:
+		return &ed25519Algorithm{privateKey: k, publicKey: k.Public().(ed25519.PublicKey)}, nil
+	}
+
+	return nil, fmt.Errorf("Unsupported key type %T.", key)
+}
+
+// REMARKS: Ref: https://www.rfc-editor.org/rfc/rfc9530#section-2
+func verifyContentDigest(request *http.Request, maxBodySize int) error {
+	var body []byte
+
+	if request.Body != nil {
+		b, err := ioutil.ReadAll(io.LimitReader(request.Body, int64(maxBodySize)+1))
+		request.Body.Close()
+
+		if err != nil {
+			return err
+		}
+
+		if len(b) > maxBodySize {
+			return fmt.Errorf("The body is larger than %d bytes.", maxBodySize)
+		}
+
+		body = b
+		request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	algorithms := map[string]func() hash.Hash{
+		"sha-256": sha256.New,
+		"sha-512": sha512.New,
+	}
+
+	for _, member := range splitStructured(strings.Join(request.Header.Values("Content-Digest"), ","), ',') {
+		name, value, ok := splitMember(member)
+		newHash, supported := algorithms[name]
+
+		if !ok || !supported {
+			continue
+		}
+
+		hash := newHash()
+		hash.Write(body)
+
+		if value != ":"+base64.StdEncoding.EncodeToString(hash.Sum(nil))+":" {
+			return errors.New("The Content-Digest does not match the body.")
+		}
+
+		return nil
+	}
+
+	return errors.New("The Content-Digest is missing, or uses an unsupported algorithm.")
+}
+
+// REMARKS: Parses `("@method" "content-type");created=1618884473;keyid="key"` into its components and parameters.
+func parseSignatureInput(input string) ([]signatureComponent, map[string]string, error) {
+	if !strings.HasPrefix(input, "(") || !strings.Contains(input, ")") {
+		return nil, nil, fmt.Errorf("Invalid signature input %q.", input)
+	}
+
+	end := strings.LastIndex(input, ")")
+
+	var components []signatureComponent
+
+	for _, item := range splitStructured(input[1:end], ' ') {
+		parts := splitStructured(item, ';')
+		name, err := strconv.Unquote(parts[0])
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("Invalid component identifier %q.", item)
+		}
+
+		component := signatureComponent{name: name}
+
+		if len(parts) > 1 {
+			component.params = ";" + strings.Join(parts[1:], ";")
+		}
+
+		components = append(components, component)
+	}
+
+	params := make(map[string]string)
+
+	for _, param := range splitStructured(input[end+1:], ';') {
+		key, value, ok := splitMember(param)
+
+		if !ok {
+			continue
+		}
+
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+
+		params[key] = value
+	}
+
+	return components, params, nil
+}
+
+// REMARKS: Splits a structured field (RFC 8941) on sep, ignoring the separators inside strings and inner lists.
+func splitStructured(s string, sep byte) []string {
+	var items []string
+	var quoted, escaped bool
+	depth, start := 0, 0
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && c == '\\':
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == sep && depth == 0:
+			if item := strings.TrimSpace(s[start:i]); item != "" {
+				items = append(items, item)
+			}
+
+			start = i + 1
+		}
+	}
+
+	if item := strings.TrimSpace(s[start:]); item != "" {
+		items = append(items, item)
+	}
+
+	return items
+}
+
+func splitMember(member string) (key, value string, ok bool) {
+	i := strings.Index(member, "=")
+
+	if i <= 0 {
+		return "", "", false
+	}
+
+	return strings.TrimSpace(member[:i]), strings.TrimSpace(member[i+1:]), true
+}
@@ -0,0 +1,38 @@
+package request
+
+import "net/http"
+
+// REMARKS: Lets a plain function be used wherever an http.RoundTripper is expected (e.g. WithTransport).
+func (f RoundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+// REMARKS: Applies the authorization method on every round trip (including retries) instead of once at Build time.
+func NewAuthMiddleware(auth AuthorizationMethod) Middleware {
+	if handler, ok := auth.(AuthorizationHandler); ok {
+		return handler.Wrap
+	}
+
+	return func(next RoundTripperFunc) RoundTripperFunc {
+		return func(request *http.Request) (*http.Response, error) {
+			auth.Configure(request)
+
+			return next(request)
+		}
+	}
+}
+
+// ***********************************************
+// ********** Private methods/functions **********
+// ***********************************************
+
+// REMARKS: The first middleware is the outermost one: it sees the request first and the response last.
+func chainMiddleware(final RoundTripperFunc, middleware []Middleware) RoundTripperFunc {
+	next := final
+
+	for i := len(middleware) - 1; i >= 0; i-- {
+		next = middleware[i](next)
+	}
+
+	return next
+}
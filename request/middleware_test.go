@@ -0,0 +1,119 @@
+package request
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestDoWithMiddlewareOrder(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, []string{"client", "builder"}, req.Header.Values("X-Trace"), "Should have run middleware in order")
+
+		resp.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	var calls []string
+
+	trace := func(name string) Middleware {
+		return func(next RoundTripperFunc) RoundTripperFunc {
+			return func(request *http.Request) (*http.Response, error) {
+				calls = append(calls, name+":request")
+				request.Header.Add("X-Trace", name)
+
+				resp, err := next(request)
+
+				calls = append(calls, name+":response")
+
+				return resp, err
+			}
+		}
+	}
+
+	req, err := NewClient().WithMiddleware(trace("client")).NewRequestBuilder().WithUrl(ts.URL).WithMiddleware(trace("builder")).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r, err := req.Do()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, http.StatusOK, r.Response().StatusCode, "Should equal HTTP Status 200 (OK)")
+	assert.Equal(t, []string{"client:request", "builder:request", "builder:response", "client:response"}, calls, "Should equal call order")
+	assert.Empty(t, req.getUnderlyingRequest().Header.Get("X-Trace"), "Should not modify the built request")
+}
+
+func TestRequestDoWithMiddlewareError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		resp.WriteHeader(http.StatusTeapot)
+	}))
+	defer ts.Close()
+
+	errUnexpectedStatus := errors.New("unexpected status")
+
+	validate := func(next RoundTripperFunc) RoundTripperFunc {
+		return func(request *http.Request) (*http.Response, error) {
+			resp, err := next(request)
+
+			if err == nil && resp.StatusCode != http.StatusOK {
+				return resp, errUnexpectedStatus
+			}
+
+			return resp, err
+		}
+	}
+
+	req, err := NewRequestBuilder().WithUrl(ts.URL).WithMiddleware(validate).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r, err := req.Do()
+
+	assert.Nil(t, r, "Should be nil")
+
+	_, ok := err.(*TransportError)
+
+	assert.True(t, ok, "Should be a TransportError")
+	assert.True(t, errors.Is(err, errUnexpectedStatus), "Should unwrap to the middleware error")
+}
+
+func TestNewAuthMiddleware(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "Bearer "+TEST_TOKEN, req.Header.Get("Authorization"), "Should equal authorization header")
+
+		resp.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	req, err := NewRequestBuilder().WithUrl(ts.URL).WithMiddleware(NewAuthMiddleware(newAuthBearer(TEST_TOKEN))).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r, err := req.Do()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, http.StatusOK, r.Response().StatusCode, "Should equal HTTP Status 200 (OK)")
+	assert.Empty(t, req.getUnderlyingRequest().Header.Get("Authorization"), "Should not modify the built request")
+}
+
+func TestRoundTripperFuncAsTransport(t *testing.T) {
+	transport := RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusNoContent,
+			Body:       http.NoBody,
+			Request:    request,
+		}, nil
+	})
+
+	req, err := NewRequestBuilder().WithUrl(POSTMAN_ECHO_ROOT).WithTransport(transport).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r, err := req.Do()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, http.StatusNoContent, r.Response().StatusCode, "Should equal HTTP Status 204 (No Content)")
+}
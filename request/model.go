@@ -2,14 +2,24 @@ package request
 
 import (
 	"bytes"
+	"context"
+	"io"
 	"net/http"
+	"net/url"
 	"time"
 )
 
 // TODO: Document interfaces
 
+// REMARKS: A Request is safe for concurrent use; every call to Do sends its own copy of the underlying *http.Request.
+// The exceptions are bodies that can only be read once (WithBodyReader, WithMultipartBody), and the values set with
+// WithResult/WithErrorResult, which every call decodes into.
 type Request interface {
-	Do() Response
+	Do() (Response, error)
+	DoContext(ctx context.Context) (Response, error)
+	DoStream() (Response, error)
+	DoStreamContext(ctx context.Context) (Response, error)
+	AsCurl() string
 	getUnderlyingRequest() *http.Request
 	getUnderlyingHttpClient() *http.Client
 }
@@ -17,28 +27,309 @@ type Request interface {
 type Response interface {
 	Body() []byte
 	Response() *http.Response
+	Text() string
+	StatusCode() int
+	Header(name string) string
+	IsSuccess() bool
+	IsClientError() bool
+	IsServerError() bool
+	Json(v interface{}) error
+	Xml(v interface{}) error
+	Decode(v interface{}) error
+	BodyReader() io.ReadCloser
+	WriteToFile(path string, progress ProgressFunc) (int64, error)
+	CacheStatus() CacheStatus
+	Close() error
 }
 
+// REMARKS: CacheNone when the request did not go through a cache (see WithCache). CacheRevalidated is a stored
+// response confirmed by the server (304 Not Modified), and CacheStale a stored response served while it is being
+// revalidated in the background (stale-while-revalidate).
+type CacheStatus int
+
+const (
+	CacheNone CacheStatus = iota
+	CacheMiss
+	CacheHit
+	CacheRevalidated
+	CacheStale
+)
+
+// REMARKS: Keys are absolute URLs; values are opaque. Implementations must be safe for concurrent use, and may drop
+// entries at any time.
+type CacheStore interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte)
+	Delete(key string)
+}
+
+// REMARKS: total is -1 when the size of the content is not known in advance.
+type ProgressFunc func(written, total int64)
+
+// REMARKS: Configure is called once by Build, and can be implemented outside of this package to support custom
+// schemes; see AuthorizationHandler for methods that need to act when the request is sent.
 type AuthorizationMethod interface {
 	Configure(request *http.Request)
 }
 
+type ApiKeyPlacement int
+
+const (
+	ApiKeyInHeader ApiKeyPlacement = iota
+	ApiKeyInQuery
+	ApiKeyInCookie
+)
+
+// REMARKS: Implemented by authorization methods that take part in sending the request, rather than only setting
+// headers up front (e.g. fetching a token, or answering a 401 challenge). Wrap runs as the innermost middleware.
+type AuthorizationHandler interface {
+	AuthorizationMethod
+	Wrap(next RoundTripperFunc) RoundTripperFunc
+}
+
+type OAuth2 interface {
+	AuthorizationHandler
+	Token(ctx context.Context) (string, error)
+	WithScopes(scopes ...string) OAuth2
+	WithClientCredentialsInBody() OAuth2
+	WithHttpClient(client *http.Client) OAuth2
+}
+
+type AwsSigV4 interface {
+	AuthorizationHandler
+	Sign(request *http.Request) error
+	Presign(request Request, expires time.Duration) (string, error)
+	WithSessionToken(token string) AwsSigV4
+	WithPayloadMode(mode AwsPayloadMode) AwsSigV4
+}
+
+// REMARKS: Signed hashes the whole body before sending it, which requires a body that can be read again (i.e. not
+// WithBodyReader or WithMultipartBody). Streaming signs the body chunk by chunk as it is sent (aws-chunked), and
+// requires its length to be known; only S3 supports it.
+type AwsPayloadMode int
+
+const (
+	AwsPayloadSigned AwsPayloadMode = iota
+	AwsPayloadUnsigned
+	AwsPayloadStreaming
+)
+
+type MessageSignature interface {
+	AuthorizationHandler
+	Sign(request *http.Request) error
+	WithComponents(components ...string) MessageSignature
+	WithLabel(label string) MessageSignature
+	WithExpiry(expiry time.Duration) MessageSignature
+	WithTag(tag string) MessageSignature
+}
+
+type MessageVerifier interface {
+	Verify(request *http.Request) error
+	Handler(next http.Handler) http.Handler
+	WithRequiredComponents(components ...string) MessageVerifier
+	WithMaxAge(maxAge time.Duration) MessageVerifier
+	WithMaxBodySize(size int) MessageVerifier
+}
+
+type RoundTripperFunc func(request *http.Request) (*http.Response, error)
+
+type Middleware func(next RoundTripperFunc) RoundTripperFunc
+
+// REMARKS: Satisfied by *slog.Logger (log/slog); args are alternating keys and values.
+type Logger interface {
+	Info(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// REMARKS: By default only the method, URL, status and latency are logged.
+type LogPolicy interface {
+	WithHeaders() LogPolicy
+	WithBodies(maxSize int) LogPolicy
+	WithRedactedHeaders(names ...string) LogPolicy
+	WithRedactedFields(names ...string) LogPolicy
+}
+
+// REMARKS: Safe for concurrent use, except for the With methods. Save and WriteTo include the entries whose response
+// body has been read to the end or closed.
+type HarRecorder interface {
+	RoundTripper(next http.RoundTripper) http.RoundTripper
+	WithMaxBodySize(size int) HarRecorder
+	WithRedactedHeaders(names ...string) HarRecorder
+	WithRedactedFields(names ...string) HarRecorder
+	Len() int
+	Reset()
+	Save(path string) error
+	WriteTo(w io.Writer) (int64, error)
+}
+
+// REMARKS: CassetteRecordMissing replays the recorded interactions and records the others, CassetteRecord records
+// every interaction again, CassetteReplay fails the requests that were not recorded, and CassettePassthrough sends
+// every request without recording it.
+type CassetteMode int
+
+const (
+	CassetteRecordMissing CassetteMode = iota
+	CassetteRecord
+	CassetteReplay
+	CassettePassthrough
+)
+
+// REMARKS: Reports whether a request being sent matches a recorded one. Both have been through the request
+// sanitizers (see Cassette.WithRequestSanitizer).
+type CassetteMatcher func(request, recorded *CassetteRequest) bool
+
+// REMARKS: A request as recorded in a cassette; the URL is absolute.
+type CassetteRequest struct {
+	Method string
+	Url    string
+	Header http.Header
+	Body   []byte
+}
+
+type CassetteResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// REMARKS: A RoundTripper, to be passed to WithTransport. Safe for concurrent use, except for the With methods.
+type Cassette interface {
+	RoundTrip(request *http.Request) (*http.Response, error)
+	WithMode(mode CassetteMode) Cassette
+	WithTransport(transport http.RoundTripper) Cassette
+	WithRedactedHeaders(names ...string) Cassette
+	WithMatchers(matchers ...CassetteMatcher) Cassette
+	WithRequestSanitizer(sanitizer func(request *CassetteRequest)) Cassette
+	WithResponseSanitizer(sanitizer func(response *CassetteResponse)) Cassette
+	Len() int
+	Save() error
+}
+
+// REMARKS: MaxAttempts includes the first attempt. Delay receives the number of attempts made so far, and the
+// last response when there is one (err and response are mutually exclusive in ShouldRetry).
+type RetryPolicy interface {
+	MaxAttempts() int
+	ShouldRetry(request *http.Request, response *http.Response, err error) bool
+	Delay(attempt int, response *http.Response) time.Duration
+}
+
+type BackoffRetryPolicy interface {
+	RetryPolicy
+	WithBaseDelay(delay time.Duration) BackoffRetryPolicy
+	WithMaxDelay(delay time.Duration) BackoffRetryPolicy
+	WithJitter(jitter bool) BackoffRetryPolicy
+	WithRetryOnStatus(predicate func(status int) bool) BackoffRetryPolicy
+	WithRetryOnError(predicate func(err error) bool) BackoffRetryPolicy
+	WithNonIdempotentMethods() BackoffRetryPolicy
+}
+
+// REMARKS: RawData is nil for bodies that are streamed from a reader rather than held in memory.
 type RequestBody interface {
 	ContentType() string
 	RawData() *bytes.Buffer
+	Reader() io.Reader
 }
 
+type BodyPolicy int
+
+const (
+	BodyAllowed BodyPolicy = iota
+	BodyIgnored
+	BodyForbidden
+)
+
+type MultipartBuilder interface {
+	Build() (RequestBody, error)
+	WithField(name, value string) MultipartBuilder
+	WithFile(name, path string) MultipartBuilder
+	WithReader(name, filename, contentType string, reader io.Reader) MultipartBuilder
+}
+
+type RequestTemplate interface {
+	NewRequestBuilder() RequestBuilder
+}
+
+// REMARKS: A RequestBuilder is not safe for concurrent use. Give each goroutine its own, with Clone or from a
+// RequestTemplate (which is safe to share).
 type RequestBuilder interface {
-	Build() Request
+	Build() (Request, error)
+	Clone() RequestBuilder
+	Template() RequestTemplate
 	WithTextBody(data string) RequestBuilder
 	WithJsonBody(data interface{}) RequestBuilder
+	WithFormBody(values url.Values) RequestBuilder
+	WithFormStruct(data interface{}) RequestBuilder
+	WithBodyReader(reader io.Reader, contentType string) RequestBuilder
+	WithMultipartBody(multipart MultipartBuilder) RequestBuilder
 	WithRFC1738(url string) RequestBuilder
 	WithHeader(name, value string) RequestBuilder
+	WithCookie(cookie *http.Cookie) RequestBuilder
 	WithMethod(method string) RequestBuilder
+	WithBodyPolicy(method string, policy BodyPolicy) RequestBuilder
 	WithUrl(url string) RequestBuilder
+	WithBaseUrl(url string) RequestBuilder
+	WithPathParam(name, value string) RequestBuilder
+	WithQueryParam(key, value string) RequestBuilder
+	WithQueryParams(values url.Values) RequestBuilder
+	WithQueryStruct(data interface{}) RequestBuilder
+	WithAuth(methods ...AuthorizationMethod) RequestBuilder
 	WithBasicAuth(username, password string) RequestBuilder
 	WithBearerAuth(token string) RequestBuilder
+	WithDigestAuth(username, password string) RequestBuilder
 	WithTimeout(timeout time.Duration) RequestBuilder
+	WithContext(ctx context.Context) RequestBuilder
+	WithRetry(policy RetryPolicy) RequestBuilder
+	WithHttpClient(client *http.Client) RequestBuilder
+	WithTransport(transport http.RoundTripper) RequestBuilder
+	WithMiddleware(middleware ...Middleware) RequestBuilder
+	WithCache(store CacheStore) RequestBuilder
+	WithLogger(logger Logger) RequestBuilder
+	WithLogPolicy(policy LogPolicy) RequestBuilder
+	WithHarRecorder(recorder HarRecorder) RequestBuilder
+	WithResult(result interface{}) RequestBuilder
+	WithErrorResult(result interface{}) RequestBuilder
 }
 
+// REMARKS: A Client is safe for concurrent use, including reconfiguring it while requests are being made.
+type Client interface {
+	NewRequestBuilder() RequestBuilder
+	HttpClient() *http.Client
+	WithTransport(transport http.RoundTripper) Client
+	WithTimeout(timeout time.Duration) Client
+	WithBaseUrl(url string) Client
+	WithMiddleware(middleware ...Middleware) Client
+	WithCache(store CacheStore) Client
+	WithLogger(logger Logger) Client
+	WithLogPolicy(policy LogPolicy) Client
+	WithHarRecorder(recorder HarRecorder) Client
+}
+
+// REMARKS: See Client; builders created from a Session share its cookie jar. The With* methods return the Session,
+// so a Session is not a Client itself.
+type Session interface {
+	NewRequestBuilder() RequestBuilder
+	HttpClient() *http.Client
+	WithTransport(transport http.RoundTripper) Session
+	WithTimeout(timeout time.Duration) Session
+	WithBaseUrl(url string) Session
+	WithMiddleware(middleware ...Middleware) Session
+	WithCache(store CacheStore) Session
+	WithLogger(logger Logger) Session
+	WithLogPolicy(policy LogPolicy) Session
+	WithHarRecorder(recorder HarRecorder) Session
+	Jar() http.CookieJar
+	Cookies(url string) ([]*http.Cookie, error)
+	SetCookies(url string, cookies ...*http.Cookie) error
+	SaveCookies(path string, format CookieFormat) error
+	LoadCookies(path string, format CookieFormat) error
+}
+
+// REMARKS: Netscape is the cookies.txt format of curl and wget.
+type CookieFormat int
+
+const (
+	CookieFormatNetscape CookieFormat = iota
+	CookieFormatJson
+)
+
 type RequestBuilderConstructor func() RequestBuilder
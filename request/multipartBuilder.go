@@ -0,0 +1,218 @@
+package request
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// TODO: Document
+
+type multipartBuilder struct {
+	parts []multipartPart
+	err   error
+}
+
+// REMARKS: Exactly one of value, path or reader is set, depending on how the part was added.
+type multipartPart struct {
+	name        string
+	filename    string
+	contentType string
+	value       string
+	path        string
+	reader      io.Reader
+}
+
+func (m *multipartBuilder) WithField(name, value string) MultipartBuilder {
+	m.parts = append(m.parts, multipartPart{name: name, value: value})
+
+	return m
+}
+
+// REMARKS: The file is only opened (and streamed) when the request is sent; its Content-Type is guessed from the extension.
+func (m *multipartBuilder) WithFile(name, path string) MultipartBuilder {
+	if _, err := os.Stat(path); err != nil {
+		m.setError(err)
+
+		return m
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(path))
+
+	if contentType == "" {
+		contentType = defaultPartContentType
+	}
+
+	m.parts = append(m.parts, multipartPart{
+		name:        name,
+		filename:    filepath.Base(path),
+		contentType: contentType,
+		path:        path,
+	})
+
+	return m
+}
+
+func (m *multipartBuilder) WithReader(name, filename, contentType string, reader io.Reader) MultipartBuilder {
+	if reader == nil {
+		m.setError(errors.New("Reader is required."))
+
+		return m
+	}
+
+	if contentType == "" {
+		contentType = defaultPartContentType
+	}
+
+	m.parts = append(m.parts, multipartPart{
+		name:        name,
+		filename:    filename,
+		contentType: contentType,
+		reader:      reader,
+	})
+
+	return m
+}
+
+func (m *multipartBuilder) Build() (RequestBody, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	// REMARKS: The boundary is fixed up front, so the Content-Type header and the streamed content agree.
+	boundary := multipart.NewWriter(ioutil.Discard).Boundary()
+
+	return &multipartBody{
+		contentType: "multipart/form-data; boundary=" + boundary,
+		boundary:    boundary,
+		parts:       m.parts,
+	}, nil
+}
+
+// ***********************************************
+// ********** Private methods/functions **********
+// ***********************************************
+
+func (m *multipartBuilder) setError(err error) {
+	if m.err == nil {
+		m.err = err
+	}
+}
+
+type multipartBody struct {
+	contentType string
+	boundary    string
+	parts       []multipartPart
+}
+
+func (b *multipartBody) ContentType() string {
+	return b.contentType
+}
+
+func (b *multipartBody) RawData() *bytes.Buffer {
+	return nil
+}
+
+// REMARKS: The form is written into a pipe as the request is sent, so files are never fully held in memory.
+func (b *multipartBody) Reader() io.Reader {
+	pr, pw := io.Pipe()
+
+	return &multipartReader{
+		body: b,
+		pr:   pr,
+		pw:   pw,
+	}
+}
+
+// REMARKS: Writing only starts on the first Read; a request that is built but never sent doesn't leave a goroutine behind.
+type multipartReader struct {
+	body *multipartBody
+	once sync.Once
+	pr   *io.PipeReader
+	pw   *io.PipeWriter
+}
+
+func (r *multipartReader) Read(p []byte) (int, error) {
+	r.once.Do(func() {
+		go r.write()
+	})
+
+	return r.pr.Read(p)
+}
+
+// REMARKS: Closing before the form has been fully read (e.g. the request failed) stops the writer.
+func (r *multipartReader) Close() error {
+	r.once.Do(func() {})
+
+	return r.pr.Close()
+}
+
+func (r *multipartReader) write() {
+	writer := multipart.NewWriter(r.pw)
+
+	if err := writer.SetBoundary(r.body.boundary); err != nil {
+		r.pw.CloseWithError(err)
+
+		return
+	}
+
+	for _, part := range r.body.parts {
+		if err := writePart(writer, part); err != nil {
+			r.pw.CloseWithError(err)
+
+			return
+		}
+	}
+
+	r.pw.CloseWithError(writer.Close())
+}
+
+func writePart(writer *multipart.Writer, part multipartPart) error {
+	if part.path == "" && part.reader == nil {
+		return writer.WriteField(part.name, part.value)
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, escapeQuotes(part.name), escapeQuotes(part.filename)))
+	header.Set("Content-Type", part.contentType)
+
+	dst, err := writer.CreatePart(header)
+
+	if err != nil {
+		return err
+	}
+
+	reader := part.reader
+
+	if part.path != "" {
+		file, err := os.Open(part.path)
+
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+
+		reader = file
+	}
+
+	_, err = io.Copy(dst, reader)
+
+	return err
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// REMARKS: Same escaping as mime/multipart uses for CreateFormFile.
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
@@ -0,0 +1,84 @@
+package request
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultipartBuilderErrorWithMissingFile(t *testing.T) {
+	body, err := NewMultipartBuilder().WithFile("file", filepath.Join(os.TempDir(), "gorequest-does-not-exist")).Build()
+
+	assert.Nil(t, body, "Should be nil")
+	assert.NotNil(t, err, "Should not be nil")
+}
+
+func TestMultipartBuilderContentType(t *testing.T) {
+	body, err := NewMultipartBuilder().WithField("field", "value").Build()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.True(t, strings.HasPrefix(body.ContentType(), "multipart/form-data; boundary="), "Should equal Content-Type")
+	assert.Nil(t, body.RawData(), "Should be nil")
+}
+
+func TestRequestDoWithMultipartBody(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gorequest")
+
+	assert.Nil(t, err, "Should be nil")
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "hello.txt")
+
+	assert.Nil(t, ioutil.WriteFile(path, []byte("Hello File"), 0600), "Should be nil")
+
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		assert.Nil(t, req.ParseMultipartForm(1<<20), "Should be nil")
+
+		assert.Equal(t, "value", req.FormValue("field"), "Should equal field value")
+
+		file, header, err := req.FormFile("file")
+
+		assert.Nil(t, err, "Should be nil")
+		assert.Equal(t, "hello.txt", header.Filename, "Should equal filename")
+		assert.True(t, strings.HasPrefix(header.Header.Get("Content-Type"), "text/plain"), "Should equal part Content-Type")
+
+		b, _ := ioutil.ReadAll(file)
+
+		assert.Equal(t, "Hello File", string(b), "Should equal file content")
+
+		reader, header, err := req.FormFile("reader")
+
+		assert.Nil(t, err, "Should be nil")
+		assert.Equal(t, "data.json", header.Filename, "Should equal filename")
+		assert.Equal(t, "application/json", header.Header.Get("Content-Type"), "Should equal part Content-Type")
+
+		b, _ = ioutil.ReadAll(reader)
+
+		assert.Equal(t, `{"hello":"world"}`, string(b), "Should equal reader content")
+
+		resp.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	multipart := NewMultipartBuilder().
+		WithField("field", "value").
+		WithFile("file", path).
+		WithReader("reader", "data.json", "application/json", strings.NewReader(`{"hello":"world"}`))
+
+	req, err := NewRequestBuilder().WithMethod("POST").WithUrl(ts.URL).WithMultipartBody(multipart).Build()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.True(t, strings.HasPrefix(req.getUnderlyingRequest().Header.Get("Content-Type"), "multipart/form-data; boundary="), "Should equal Content-Type header")
+
+	r, err := req.Do()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, http.StatusOK, r.StatusCode(), "Should equal HTTP Status 200 (OK)")
+}
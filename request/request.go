@@ -1,19 +1,34 @@
 package request
 
 import (
+	"context"
 	"io/ioutil"
 	"net/http"
+	"time"
 )
 
 type request struct {
-	request *http.Request
-	client  *http.Client
+	request   *http.Request
+	client    *http.Client
+	options   requestOptions
+	roundTrip RoundTripperFunc
 }
 
-func newRequest(req *http.Request, client *http.Client) Request {
+// REMARKS: Options set on the builder that only come into play when the request is sent.
+type requestOptions struct {
+	auth        AuthorizationMethod
+	retry       RetryPolicy
+	middleware  []Middleware
+	result      interface{}
+	errorResult interface{}
+}
+
+func newRequest(req *http.Request, client *http.Client, options requestOptions) Request {
 	return &request{
-		request: req,
-		client:  client,
+		request:   req,
+		client:    client,
+		options:   options,
+		roundTrip: chainMiddleware(client.Do, options.middleware),
 	}
 }
 
@@ -25,23 +40,167 @@ func (r *request) getUnderlyingHttpClient() *http.Client {
 	return r.client
 }
 
-func (r *request) Do() Response {
-	resp, err := r.client.Do(r.request)
+// REMARKS: An equivalent curl command, to reproduce the request in a terminal (see formatCurl for its limits).
+func (r *request) AsCurl() string {
+	return formatCurl(r.request, r.options.auth)
+}
 
-	defer resp.Body.Close()
+func (r *request) Do() (Response, error) {
+	return r.DoContext(r.request.Context())
+}
+
+func (r *request) DoContext(ctx context.Context) (Response, error) {
+	resp, err := r.execute(ctx, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.decode(resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (r *request) DoStream() (Response, error) {
+	return r.DoStreamContext(r.request.Context())
+}
+
+// REMARKS: The body is left unread; the caller reads it through BodyReader (or WriteToFile) and must Close the response.
+// REMARKS: WithResult and WithErrorResult do not apply, since decoding would require reading the body.
+func (r *request) DoStreamContext(ctx context.Context) (Response, error) {
+	return r.execute(ctx, true)
+}
+
+// ***********************************************
+// ********** Private methods/functions **********
+// ***********************************************
+
+func (r *request) execute(ctx context.Context, stream bool) (Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := r.send(ctx, stream)
+
+		if _, ok := err.(*ContextError); ok {
+			return nil, err
+		}
+
+		if attempt >= r.options.retry.MaxAttempts() || !r.canReplay() {
+			return resp, err
+		}
+
+		var underlying *http.Response
+
+		if resp != nil {
+			underlying = resp.Response()
+		}
+
+		if !r.options.retry.ShouldRetry(r.request, underlying, err) {
+			return resp, err
+		}
+
+		// REMARKS: The response is being discarded; a streamed body would otherwise keep its connection busy.
+		if resp != nil {
+			resp.Close()
+		}
+
+		timer := time.NewTimer(r.options.retry.Delay(attempt, underlying))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return nil, r.wrapError(ctx, err)
+		case <-timer.C:
+		}
+	}
+}
+
+// REMARKS: Each attempt gets a fresh copy of the body from GetBody; the body set by Build is consumed by the first send.
+// REMARKS: Each attempt also gets its own copy of the request, so middleware can modify it (e.g. add headers) without affecting later attempts.
+func (r *request) send(ctx context.Context, stream bool) (Response, error) {
+	req := r.request.Clone(ctx)
+
+	if r.request.GetBody != nil {
+		body, err := r.request.GetBody()
+
+		if err != nil {
+			return nil, &TransportError{Method: req.Method, Url: req.URL.String(), Err: err}
+		}
+
+		req.Body = body
+	}
+
+	resp, err := r.roundTrip(req)
 
 	if err != nil {
-		panic(err)
+		// REMARKS: A middleware may return a response along with an error; its body would otherwise leak.
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		return nil, r.wrapError(ctx, &TransportError{Method: req.Method, Url: req.URL.String(), Err: err})
+	}
+
+	if stream {
+		return &response{
+			response: resp,
+			stream:   true,
+		}, nil
 	}
 
+	defer resp.Body.Close()
+
 	body, err := ioutil.ReadAll(resp.Body)
 
 	if err != nil {
-		panic(err)
+		return nil, r.wrapError(ctx, &BodyReadError{Response: resp, Err: err})
 	}
 
 	return &response{
 		body:     body,
 		response: resp,
+	}, nil
+}
+
+// REMARKS: Only the payload matching the status is decoded: 2xx into the result, 4xx/5xx into the error result.
+// Bodies that are neither JSON nor XML are left undecoded, so that an error page (e.g. the HTML of a 502 from a
+// proxy) doesn't hide the response.
+func (r *request) decode(resp Response) error {
+	var target interface{}
+
+	switch {
+	case resp.IsSuccess():
+		target = r.options.result
+	case resp.IsClientError(), resp.IsServerError():
+		target = r.options.errorResult
+	}
+
+	if target == nil || len(resp.Body()) == 0 {
+		return nil
+	}
+
+	if err := resp.Decode(target); err != nil {
+		if _, ok := err.(*ContentTypeError); ok {
+			return nil
+		}
+
+		return &DecodeError{Response: resp, Err: err}
 	}
+
+	return nil
+}
+
+// REMARKS: A request without a body, or whose body can be recreated, can be sent more than once.
+func (r *request) canReplay() bool {
+	return r.request.Body == nil || r.request.Body == http.NoBody || r.request.GetBody != nil
+}
+
+// REMARKS: A failure caused by the context being done is reported as a ContextError, regardless of where it surfaced.
+func (r *request) wrapError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return &ContextError{Method: r.request.Method, Url: r.request.URL.String(), Err: ctxErr}
+	}
+
+	return err
 }
@@ -4,6 +4,9 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"io"
+	"io/ioutil"
+	"net/url"
 	"reflect"
 )
 
@@ -14,6 +17,11 @@ type requestBody struct {
 	data        *bytes.Buffer
 }
 
+type streamBody struct {
+	contentType string
+	reader      io.Reader
+}
+
 func newTextBody(data string) RequestBody {
 	return &requestBody{
 		contentType: "text/plain",
@@ -21,7 +29,32 @@ func newTextBody(data string) RequestBody {
 	}
 }
 
-func newJsonBody(data interface{}) RequestBody {
+func newFormBody(values url.Values) RequestBody {
+	return &requestBody{
+		contentType: "application/x-www-form-urlencoded",
+		data:        bytes.NewBufferString(values.Encode()),
+	}
+}
+
+// REMARKS: Fields are named after their `form` struct tag; see encodeValues.
+func newFormStructBody(data interface{}) (RequestBody, error) {
+	values, err := encodeValues(data, "form")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newFormBody(values), nil
+}
+
+func newStreamBody(reader io.Reader, contentType string) RequestBody {
+	return &streamBody{
+		contentType: contentType,
+		reader:      reader,
+	}
+}
+
+func newJsonBody(data interface{}) (RequestBody, error) {
 
 	var buffer *bytes.Buffer
 
@@ -35,17 +68,17 @@ func newJsonBody(data interface{}) RequestBody {
 		if rawBytes, err := json.Marshal(indirect.Interface()); err == nil {
 			buffer = bytes.NewBuffer(rawBytes)
 		} else {
-			panic(err)
+			return nil, err
 		}
 		break
 	default:
-		panic(errors.New("Can only serialize a string or a struct as JSON content."))
+		return nil, errors.New("Can only serialize a string or a struct as JSON content.")
 	}
 
 	return &requestBody{
 		contentType: "application/json",
 		data:        buffer,
-	}
+	}, nil
 }
 
 func (b *requestBody) ContentType() string {
@@ -55,3 +88,25 @@ func (b *requestBody) ContentType() string {
 func (b *requestBody) RawData() *bytes.Buffer {
 	return b.data
 }
+
+// REMARKS: A new reader over the same bytes every time; the buffer itself is never drained.
+func (b *requestBody) Reader() io.Reader {
+	return bytes.NewReader(b.data.Bytes())
+}
+
+func (b *streamBody) ContentType() string {
+	return b.contentType
+}
+
+func (b *streamBody) RawData() *bytes.Buffer {
+	return nil
+}
+
+// REMARKS: Closing the reader is left to the caller; the HTTP client would otherwise close it (e.g. an *os.File) once sent.
+func (b *streamBody) Reader() io.Reader {
+	if _, ok := b.reader.(io.Closer); ok {
+		return ioutil.NopCloser(b.reader)
+	}
+
+	return b.reader
+}
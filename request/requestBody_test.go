@@ -2,28 +2,29 @@ package request
 
 import (
 	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
-func TestNewJsonBodyPanic(t *testing.T) {
-	defer func() {
-		err := recover().(error)
+func TestNewJsonBodyError(t *testing.T) {
+	body, err := newJsonBody(10)
 
-		assert.NotNil(t, err, "Should not be nil")
-		assert.Equal(t, "Can only serialize a string or a struct as JSON content.", err.Error(), "Should equal error message")
-	}()
-
-	newJsonBody(10)
-
-	assert.True(t, false, "Should not have completed test")
+	assert.Nil(t, body, "Should be nil")
+	assert.NotNil(t, err, "Should not be nil")
+	assert.Equal(t, "Can only serialize a string or a struct as JSON content.", err.Error(), "Should equal error message")
 }
 
 func TestNewJsonBodyWithString(t *testing.T) {
 	b := "Hello World"
 
-	body := newJsonBody(b)
+	body, err := newJsonBody(b)
+
+	assert.Nil(t, err, "Should be nil")
 
 	assert.NotNil(t, body, "Should not be nil")
 	assert.Equal(t, "application/json", body.ContentType(), "Should equal Content-Type")
@@ -40,7 +41,9 @@ func TestNewJsonBodyWithStruct(t *testing.T) {
 
 	assert.Nil(t, err, "Should be nil")
 
-	body := newJsonBody(testJsonData)
+	body, err := newJsonBody(testJsonData)
+
+	assert.Nil(t, err, "Should be nil")
 
 	assert.NotNil(t, body, "Should not be nil")
 	assert.Equal(t, "application/json", body.ContentType(), "Should equal Content-Type")
@@ -56,3 +59,56 @@ func TestNewJsonBodyWithStruct(t *testing.T) {
 	assert.Equal(t, testJsonData.StringField, r.StringField, "Should be equal")
 	assert.Equal(t, testJsonData.BoolField, r.BoolField, "Should be equal")
 }
+
+func TestNewTextBodyReader(t *testing.T) {
+	body := newTextBody("Hello World")
+
+	for i := 0; i < 2; i++ {
+		b, err := ioutil.ReadAll(body.Reader())
+
+		assert.Nil(t, err, "Should be nil")
+		assert.Equal(t, "Hello World", string(b), "Should equal body on every read")
+	}
+}
+
+func TestNewStreamBody(t *testing.T) {
+	file, err := ioutil.TempFile("", "gorequest")
+
+	assert.Nil(t, err, "Should be nil")
+
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	body := newStreamBody(file, "application/octet-stream")
+
+	assert.Equal(t, "application/octet-stream", body.ContentType(), "Should equal Content-Type")
+	assert.Nil(t, body.RawData(), "Should be nil")
+
+	rc, ok := body.Reader().(io.ReadCloser)
+
+	assert.True(t, ok, "Should be a ReadCloser")
+	assert.Nil(t, rc.Close(), "Should be nil")
+
+	_, err = file.Stat()
+
+	assert.Nil(t, err, "Should not have closed the underlying file")
+}
+
+func TestNewFormBody(t *testing.T) {
+	body := newFormBody(url.Values{"grant_type": []string{"client_credentials"}, "scope": []string{"read write"}})
+
+	assert.Equal(t, "application/x-www-form-urlencoded", body.ContentType(), "Should equal Content-Type")
+	assert.Equal(t, "grant_type=client_credentials&scope=read+write", body.RawData().String(), "Should equal RawData")
+}
+
+func TestNewFormStructBody(t *testing.T) {
+	body, err := newFormStructBody(&testValuesStruct{Name: "Hello World"})
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, "application/x-www-form-urlencoded", body.ContentType(), "Should equal Content-Type")
+
+	values, err := url.ParseQuery(body.RawData().String())
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, "Hello World", values.Get("name"), "Should equal field value")
+}
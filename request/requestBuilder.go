@@ -2,8 +2,12 @@ package request
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strings"
 	"time"
@@ -11,13 +15,31 @@ import (
 
 // TODO: Document
 
+var pathParamRegex = regexp.MustCompile(`\{[^{}/]+\}`)
+
 type requestBuilder struct {
-	auth    AuthorizationMethod
-	body    RequestBody
-	headers map[string]string
-	method  string
-	url     string
-	timeout time.Duration
+	auth       AuthorizationMethod
+	body       RequestBody
+	headers    map[string]string
+	cookies    []*http.Cookie
+	method     string
+	url        string
+	baseUrl    string
+	pathParams map[string]string
+	timeout    time.Duration
+	ctx        context.Context
+	retry      RetryPolicy
+	client     *http.Client
+	middleware []Middleware
+	cache      *httpCache
+	logger     Logger
+	logPolicy  LogPolicy
+	recorder   HarRecorder
+	query      url.Values
+	policies   map[string]BodyPolicy
+	result     interface{}
+	errResult  interface{}
+	err        error
 }
 
 func (b *requestBuilder) WithUrl(url string) RequestBuilder {
@@ -26,20 +48,68 @@ func (b *requestBuilder) WithUrl(url string) RequestBuilder {
 	return b
 }
 
+// REMARKS: A URL set with WithUrl that has no scheme (e.g. "/users/{id}") is appended to the base URL; an absolute one is used as is.
+func (b *requestBuilder) WithBaseUrl(url string) RequestBuilder {
+	b.baseUrl = url
+
+	return b
+}
+
+// REMARKS: Replaces the {name} placeholder in the path of the URL; the value is escaped, so it always stays a single path segment.
+func (b *requestBuilder) WithPathParam(name, value string) RequestBuilder {
+	if b.pathParams == nil {
+		b.pathParams = make(map[string]string)
+	}
+
+	b.pathParams[name] = value
+
+	return b
+}
+
 // REMARKS: The user/pwd can be provided in the URL when doing Basic Authentication (RFC 1738)
 func (b *requestBuilder) WithRFC1738(url string) RequestBuilder {
 	u, p, e := splitUserNamePassword(url)
 
-	// TODO: Panic ?
+	b.url = url
+
 	if e != nil {
-		panic(e)
-	}
+		b.setError(&UrlError{Url: url, Err: e})
 
-	b.url = url
+		return b
+	}
 
 	return b.WithBasicAuth(u, p)
 }
 
+func (b *requestBuilder) WithQueryParam(key, value string) RequestBuilder {
+	b.query.Add(key, value)
+
+	return b
+}
+
+func (b *requestBuilder) WithQueryParams(values url.Values) RequestBuilder {
+	for k, v := range values {
+		for _, value := range v {
+			b.query.Add(k, value)
+		}
+	}
+
+	return b
+}
+
+// REMARKS: Fields are named after their `url` struct tag; see encodeValues.
+func (b *requestBuilder) WithQueryStruct(data interface{}) RequestBuilder {
+	values, err := encodeValues(data, "url")
+
+	if err != nil {
+		b.setError(&ValidationError{Option: "WithQueryStruct", Err: err})
+
+		return b
+	}
+
+	return b.WithQueryParams(values)
+}
+
 func (b *requestBuilder) WithTextBody(data string) RequestBuilder {
 	b.body = newTextBody(data)
 
@@ -47,7 +117,70 @@ func (b *requestBuilder) WithTextBody(data string) RequestBuilder {
 }
 
 func (b *requestBuilder) WithJsonBody(data interface{}) RequestBuilder {
-	b.body = newJsonBody(data)
+	body, err := newJsonBody(data)
+
+	if err != nil {
+		b.setError(&ValidationError{Option: "WithJsonBody", Err: err})
+
+		return b
+	}
+
+	b.body = body
+
+	return b
+}
+
+func (b *requestBuilder) WithFormBody(values url.Values) RequestBuilder {
+	b.body = newFormBody(values)
+
+	return b
+}
+
+func (b *requestBuilder) WithFormStruct(data interface{}) RequestBuilder {
+	body, err := newFormStructBody(data)
+
+	if err != nil {
+		b.setError(&ValidationError{Option: "WithFormStruct", Err: err})
+
+		return b
+	}
+
+	b.body = body
+
+	return b
+}
+
+// REMARKS: The body is streamed as it is read instead of being held in memory; as it can only be read once,
+// a request with this body is never retried.
+func (b *requestBuilder) WithBodyReader(reader io.Reader, contentType string) RequestBuilder {
+	if reader == nil {
+		b.setError(&ValidationError{Option: "WithBodyReader", Err: errors.New("Reader is required.")})
+
+		return b
+	}
+
+	b.body = newStreamBody(reader, contentType)
+
+	return b
+}
+
+// REMARKS: Like WithBodyReader, the form is streamed and the request is never retried.
+func (b *requestBuilder) WithMultipartBody(multipart MultipartBuilder) RequestBuilder {
+	if multipart == nil {
+		b.setError(&ValidationError{Option: "WithMultipartBody", Err: errors.New("Multipart builder is required.")})
+
+		return b
+	}
+
+	body, err := multipart.Build()
+
+	if err != nil {
+		b.setError(&ValidationError{Option: "WithMultipartBody", Err: err})
+
+		return b
+	}
+
+	b.body = body
 
 	return b
 }
@@ -58,12 +191,60 @@ func (b *requestBuilder) WithHeader(key, value string) RequestBuilder {
 	return b
 }
 
+// REMARKS: Sent along with the cookies of the client's jar, if it has one (see Session); it is not stored in the jar.
+// A nil cookie is skipped.
+func (b *requestBuilder) WithCookie(cookie *http.Cookie) RequestBuilder {
+	if cookie != nil {
+		b.cookies = append(b.cookies, cookie)
+	}
+
+	return b
+}
+
 func (b *requestBuilder) WithMethod(method string) RequestBuilder {
 	b.method = method
 
 	return b
 }
 
+// REMARKS: Standard methods are matched case-insensitively, extension methods exactly (as with WithMethod).
+func (b *requestBuilder) WithBodyPolicy(method string, policy BodyPolicy) RequestBuilder {
+	if _, ok := defaultBodyPolicies[strings.ToUpper(method)]; ok {
+		method = strings.ToUpper(method)
+	}
+
+	if b.policies == nil {
+		b.policies = make(map[string]BodyPolicy)
+	}
+
+	b.policies[method] = policy
+
+	return b
+}
+
+// REMARKS: Replaces the authorization method; several methods are applied in the order given. Nil methods are
+// skipped, so WithAuth() or WithAuth(nil) removes the authorization.
+func (b *requestBuilder) WithAuth(methods ...AuthorizationMethod) RequestBuilder {
+	var chain []AuthorizationMethod
+
+	for _, method := range methods {
+		if method != nil {
+			chain = append(chain, method)
+		}
+	}
+
+	switch len(chain) {
+	case 0:
+		b.auth = newAuthNone()
+	case 1:
+		b.auth = chain[0]
+	default:
+		b.auth = newAuthChain(chain)
+	}
+
+	return b
+}
+
 func (b *requestBuilder) WithBasicAuth(username, password string) RequestBuilder {
 	b.auth = newAuthBasic(username, password)
 
@@ -76,26 +257,205 @@ func (b *requestBuilder) WithBearerAuth(token string) RequestBuilder {
 	return b
 }
 
+// REMARKS: Each call starts a new handshake; to share the server's challenge (and nonce) between requests, pass the
+// same NewDigestAuth value to WithAuth, or build the requests from a template.
+func (b *requestBuilder) WithDigestAuth(username, password string) RequestBuilder {
+	b.auth = newAuthDigest(username, password)
+
+	return b
+}
+
 func (b *requestBuilder) WithTimeout(timeout time.Duration) RequestBuilder {
 	b.timeout = timeout
 
 	return b
 }
 
-func (b *requestBuilder) Build() Request {
-	b.validate()
+// REMARKS: The context is attached to the underlying *http.Request; it can still be overridden per call with Request.DoContext.
+func (b *requestBuilder) WithContext(ctx context.Context) RequestBuilder {
+	if ctx == nil {
+		b.setError(&ValidationError{Option: "WithContext", Err: errors.New("Context is required.")})
+
+		return b
+	}
+
+	b.ctx = ctx
+
+	return b
+}
+
+func (b *requestBuilder) WithRetry(policy RetryPolicy) RequestBuilder {
+	if policy == nil {
+		policy = newRetryNone()
+	}
+
+	b.retry = policy
+
+	return b
+}
 
-	var body *bytes.Buffer = &bytes.Buffer{}
+// REMARKS: The client's Timeout becomes the builder's timeout; a later call to WithTimeout still overrides it.
+func (b *requestBuilder) WithHttpClient(client *http.Client) RequestBuilder {
+	if client == nil {
+		b.setError(&ValidationError{Option: "WithHttpClient", Err: errors.New("HTTP client is required.")})
 
-	if b.body != nil {
-		body = b.body.RawData()
-		b.headers["Content-Type"] = b.body.ContentType()
+		return b
 	}
 
-	req, err := http.NewRequest(b.method, b.url, body)
+	b.client = client
+	b.timeout = client.Timeout
+
+	return b
+}
+
+// REMARKS: Only this builder's requests use the transport; the client set with WithHttpClient (if any) is left untouched.
+func (b *requestBuilder) WithTransport(transport http.RoundTripper) RequestBuilder {
+	client := *b.client
+	client.Transport = transport
+
+	b.client = &client
+
+	return b
+}
+
+func (b *requestBuilder) WithMiddleware(middleware ...Middleware) RequestBuilder {
+	b.middleware = append(b.middleware, middleware...)
+
+	return b
+}
+
+// REMARKS: Caches GET responses in store, following their Cache-Control, Expires, ETag/Last-Modified and Vary
+// headers (RFC 9111); see Response.CacheStatus. The cache runs after the middleware, so a retried request may be
+// served from it. Responses to requests with an authorization method, cookies or a cookie jar (Session) are only
+// stored when they are public. A nil store disables the cache.
+func (b *requestBuilder) WithCache(store CacheStore) RequestBuilder {
+	b.cache = nil
+
+	if store != nil {
+		b.cache = newHttpCache(store)
+	}
+
+	return b
+}
+
+// REMARKS: Logs every attempt (see requestLogger), after the middleware and before the cache. A nil logger disables logging.
+func (b *requestBuilder) WithLogger(logger Logger) RequestBuilder {
+	b.logger = logger
+
+	return b
+}
+
+// REMARKS: Defaults to NewLogPolicy().
+func (b *requestBuilder) WithLogPolicy(policy LogPolicy) RequestBuilder {
+	b.logPolicy = policy
+
+	return b
+}
+
+// REMARKS: Records the requests sent by the transport, see harRecorder. A nil recorder disables the recording.
+func (b *requestBuilder) WithHarRecorder(recorder HarRecorder) RequestBuilder {
+	b.recorder = recorder
+
+	return b
+}
+
+// REMARKS: The response body of a successful (2xx) request is decoded into result, based on its Content-Type.
+func (b *requestBuilder) WithResult(result interface{}) RequestBuilder {
+	b.result = result
+
+	return b
+}
+
+// REMARKS: The response body of a failed (4xx/5xx) request is decoded into result, based on its Content-Type.
+func (b *requestBuilder) WithErrorResult(result interface{}) RequestBuilder {
+	b.errResult = result
+
+	return b
+}
+
+// REMARKS: Maps and slices are copied, so options added to either builder afterwards don't leak into the other.
+// The values themselves (auth method, body, retry policy, HTTP client, result targets) are shared.
+func (b *requestBuilder) Clone() RequestBuilder {
+	clone := *b
+
+	clone.headers = make(map[string]string, len(b.headers))
+
+	for k, v := range b.headers {
+		clone.headers[k] = v
+	}
+
+	clone.cookies = append([]*http.Cookie(nil), b.cookies...)
+	clone.query = make(url.Values, len(b.query))
+
+	for k, v := range b.query {
+		clone.query[k] = append([]string(nil), v...)
+	}
+
+	if b.pathParams != nil {
+		clone.pathParams = make(map[string]string, len(b.pathParams))
+
+		for k, v := range b.pathParams {
+			clone.pathParams[k] = v
+		}
+	}
+
+	if b.policies != nil {
+		clone.policies = make(map[string]BodyPolicy, len(b.policies))
+
+		for k, v := range b.policies {
+			clone.policies[k] = v
+		}
+	}
+
+	clone.middleware = append([]Middleware(nil), b.middleware...)
+
+	return &clone
+}
+
+func (b *requestBuilder) Template() RequestTemplate {
+	return &requestTemplate{
+		builder: b.Clone(),
+	}
+}
+
+// REMARKS: Errors raised while configuring the builder (e.g. WithRFC1738, WithJsonBody) are deferred and reported here.
+func (b *requestBuilder) Build() (Request, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	// REMARKS: Build leaves the builder untouched, so it can be built again (or cloned) with the same options.
+	method, requestBody, err := b.validate()
 
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+
+	var body io.Reader = &bytes.Buffer{}
+
+	if requestBody != nil {
+		body = requestBody.Reader()
+	}
+
+	rawUrl, err := b.resolveUrl()
+
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(b.ctx, method, rawUrl, body)
+
+	if err != nil {
+		return nil, &UrlError{Url: rawUrl, Err: err}
+	}
+
+	// REMARKS: Parameters are appended to any query already present in the URL, which is left as it was given.
+	if len(b.query) > 0 {
+		if req.URL.RawQuery != "" {
+			req.URL.RawQuery += "&"
+		}
+
+		req.URL.RawQuery += b.query.Encode()
 	}
 
 	b.auth.Configure(req)
@@ -108,44 +468,184 @@ func (b *requestBuilder) Build() Request {
 		req.Header.Add(k, v)
 	}
 
-	// REMARKS: Initialize HTTP Client
-	client := newHttpClient(b.timeout)
+	for _, cookie := range b.cookies {
+		req.AddCookie(cookie)
+	}
 
-	return newRequest(req, client)
+	// REMARKS: A Content-Type set with WithHeader takes precedence over the one implied by the body.
+	if requestBody != nil && requestBody.ContentType() != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", requestBody.ContentType())
+	}
+
+	// REMARKS: Initialize HTTP Client; it shares the transport (and connection pool) of the builder's client.
+	client := deriveHttpClient(b.client, b.timeout)
+
+	if b.recorder != nil {
+		client.Transport = b.recorder.RoundTripper(client.Transport)
+	}
+
+	middleware := append([]Middleware(nil), b.middleware...)
+
+	if b.logger != nil {
+		policy, ok := b.logPolicy.(*logPolicy)
+
+		if !ok {
+			policy = newLogPolicy()
+		}
+
+		middleware = append(middleware, newRequestLogger(b.logger, policy).Wrap)
+	}
+
+	if b.cache != nil {
+		_, anonymous := b.auth.(*authNone)
+
+		middleware = append(middleware, b.cache.withAuthorization(!anonymous || client.Jar != nil).Wrap)
+	}
+
+	if handler, ok := b.auth.(AuthorizationHandler); ok {
+		middleware = append(middleware, handler.Wrap)
+	}
+
+	return newRequest(req, client, requestOptions{
+		auth:        b.auth,
+		retry:       b.retry,
+		middleware:  middleware,
+		result:      b.result,
+		errorResult: b.errResult,
+	}), nil
 }
 
 // ***********************************************
 // ********** Private methods/functions **********
 // ***********************************************
 
-func (b *requestBuilder) validate() {
-	if strings.Trim(b.url, " ") == "" {
-		panic(errors.New("URL is required."))
+// REMARKS: Only the first error is kept; it is the one most likely to explain the ones that follow.
+func (b *requestBuilder) setError(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// REMARKS: Returns the normalized method, and the body to send with it (nil when the method's policy ignores it).
+func (b *requestBuilder) validate() (string, RequestBody, error) {
+	if strings.Trim(b.url, " ") == "" && strings.Trim(b.baseUrl, " ") == "" {
+		return "", nil, &ValidationError{Option: "WithUrl", Err: errors.New("URL is required.")}
 	}
 
 	// REMARKS: Validate method and synchronize the body.
+	// REMARKS: Standard methods are matched case-insensitively and normalized; extension methods (e.g. WebDAV's PROPFIND) are sent as given.
+	method := strings.TrimSpace(b.method)
+
+	if method == "" {
+		method = defaultMethod
+	}
+
+	if _, ok := defaultBodyPolicies[strings.ToUpper(method)]; ok {
+		method = strings.ToUpper(method)
+	} else if !isToken(method) {
+		return "", nil, &ValidationError{Option: "WithMethod", Err: fmt.Errorf("Invalid method %q.", method)}
+	}
+
 	// REMARKS: For the time being, the Body of a GET request will be ignored. For more information, read below or refer to the HTTP Specification.
 	// REMARKS: There is a lot of ambiguity to suggest that most servers won't inspect the body of a GET request. Clients like Postman disable the Body tab when performing a GET request.
 	// Ref: https://www.w3.org/Protocols/rfc2616/rfc2616-sec4.html.
 	// Section 4.3: A message-body MUST NOT be included in a request if the specification of the request method (section 5.1.1) does not allow sending an entity-body in requests.
 	// Section 5.2: The exact resource identified by an Internet request is determined by examining both the Request-URI and the Host header field.
 	// Section 9.3: The GET method means retrieve whatever information (in the form of an entity) is identified by the Request-URI.
-	// REMARKS: Ignore Body of a DELETE and HEAD requests - RFC2616.
-	switch strings.ToUpper(b.method) {
-	case "POST":
-		b.method = "POST"
-	case "PUT":
-		b.method = "PUT"
-	case "DELETE":
-		b.method = "DELETE"
-		b.body = nil
-	case "HEAD":
-		b.method = "HEAD"
-		b.body = nil
-	default:
-		b.method = "GET"
-		b.body = nil
+	// REMARKS: The defaults (see defaultBodyPolicies) can be overridden per method with WithBodyPolicy.
+	switch b.bodyPolicy(method) {
+	case BodyIgnored:
+		return method, nil, nil
+	case BodyForbidden:
+		if b.body != nil {
+			return "", nil, &ValidationError{Option: "WithMethod", Err: fmt.Errorf("A body is not allowed for %s requests.", method)}
+		}
+	}
+
+	return method, b.body, nil
+}
+
+// REMARKS: Joins the base URL and the URL, then substitutes the path parameters. Placeholders are only looked for in the
+// path, so braces in the query (e.g. a JSON filter) are left alone.
+func (b *requestBuilder) resolveUrl() (string, error) {
+	rawUrl := b.url
+
+	if b.baseUrl != "" && !isAbsoluteUrl(rawUrl) {
+		if rawUrl == "" {
+			rawUrl = b.baseUrl
+		} else {
+			rawUrl = strings.TrimRight(b.baseUrl, "/") + "/" + strings.TrimLeft(rawUrl, "/")
+		}
 	}
+
+	path, rest := rawUrl, ""
+
+	if i := strings.IndexAny(rawUrl, "?#"); i >= 0 {
+		path, rest = rawUrl[:i], rawUrl[i:]
+	}
+
+	var unresolved []string
+
+	path = pathParamRegex.ReplaceAllStringFunc(path, func(placeholder string) string {
+		name := placeholder[1 : len(placeholder)-1]
+
+		if value, ok := b.pathParams[name]; ok {
+			return url.PathEscape(value)
+		}
+
+		unresolved = append(unresolved, name)
+
+		return placeholder
+	})
+
+	if len(unresolved) > 0 {
+		return "", &ValidationError{Option: "WithPathParam", Err: fmt.Errorf("Unresolved path parameters in %q: %s.", rawUrl, strings.Join(unresolved, ", "))}
+	}
+
+	return path + rest, nil
+}
+
+// REMARKS: Methods without an explicit policy (i.e. extension methods) allow a body.
+// REMARKS: Only a scheme followed by "//" makes a URL absolute: "https://" in the query doesn't, and neither does the
+// port of "localhost:8080/path". Placeholders can make a URL unparsable (e.g. in the host); the scheme is then looked
+// for before the query.
+func isAbsoluteUrl(rawUrl string) bool {
+	if u, err := url.Parse(rawUrl); err == nil {
+		return u.IsAbs() && u.Opaque == ""
+	}
+
+	if i := strings.IndexAny(rawUrl, "?#"); i >= 0 {
+		rawUrl = rawUrl[:i]
+	}
+
+	return strings.Contains(rawUrl, "://")
+}
+
+func (b *requestBuilder) bodyPolicy(method string) BodyPolicy {
+	if policy, ok := b.policies[method]; ok {
+		return policy
+	}
+
+	if policy, ok := defaultBodyPolicies[method]; ok {
+		return policy
+	}
+
+	return BodyAllowed
+}
+
+// REMARKS: Ref: https://www.rfc-editor.org/rfc/rfc9110#section-5.6.2
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, c := range s {
+		if c >= 0x80 || !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("!#$%&'*+-.^_`|~", c)) {
+			return false
+		}
+	}
+
+	return true
 }
 
 // REMARKS: The user/pwd can be provided in the URL when doing Basic Authentication (RFC 1738)
@@ -0,0 +1,11 @@
+package request
+
+// REMARKS: A snapshot of a builder's options. It has no setters and hands out clones, so it is immutable and
+// can be shared (e.g. between goroutines) to derive builders that don't leak state into each other.
+type requestTemplate struct {
+	builder RequestBuilder
+}
+
+func (t *requestTemplate) NewRequestBuilder() RequestBuilder {
+	return t.builder.Clone()
+}
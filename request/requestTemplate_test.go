@@ -0,0 +1,90 @@
+package request
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestBuilderClone(t *testing.T) {
+	base := NewRequestBuilder().WithBaseUrl(POSTMAN_ECHO_ROOT).WithHeader("X-Base", "base").WithQueryParam("base", "1")
+
+	clone := base.Clone().WithUrl("/get").WithHeader("X-Clone", "clone").WithQueryParam("clone", "1")
+
+	r1, err := clone.Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r2 := r1.getUnderlyingRequest()
+
+	assert.Equal(t, "base", r2.Header.Get("X-Base"), "Should have inherited header")
+	assert.Equal(t, "clone", r2.Header.Get("X-Clone"), "Should equal header")
+	assert.Equal(t, "base=1&clone=1", r2.URL.RawQuery, "Should equal query")
+
+	r3, err := base.Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r4 := r3.getUnderlyingRequest()
+
+	assert.Empty(t, r4.Header.Get("X-Clone"), "Should not have leaked header")
+	assert.Equal(t, "base=1", r4.URL.RawQuery, "Should not have leaked query")
+	assert.Equal(t, POSTMAN_ECHO_ROOT, r4.URL.String()[:len(POSTMAN_ECHO_ROOT)], "Should not have leaked URL")
+}
+
+func TestRequestBuilderCloneMiddleware(t *testing.T) {
+	noop := func(next RoundTripperFunc) RoundTripperFunc { return next }
+
+	// Leave spare capacity, so a shared backing array would be overwritten by the second append.
+	base := NewRequestBuilder().WithMiddleware(noop, noop, noop).WithMiddleware(noop).(*requestBuilder)
+
+	c1 := base.Clone().WithMiddleware(noop).(*requestBuilder)
+	c2 := base.Clone().(*requestBuilder)
+
+	assert.Equal(t, 5, len(c1.middleware), "Should have added middleware")
+	assert.Equal(t, 4, len(c2.middleware), "Should not have leaked middleware")
+	assert.Equal(t, 4, len(base.middleware), "Should not have leaked middleware")
+}
+
+func TestRequestBuilderBuildDoesNotModifyBuilder(t *testing.T) {
+	builder := NewRequestBuilder().WithUrl(POSTMAN_ECHO_ROOT).WithTextBody("Hello World")
+
+	r1, err := builder.Build()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, "GET", r1.getUnderlyingRequest().Method, "Should equal GET method")
+	assert.Empty(t, r1.getUnderlyingRequest().Header.Get("Content-Type"), "Should have ignored the body")
+
+	r2, err := builder.WithMethod("post").Build()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, "POST", r2.getUnderlyingRequest().Method, "Should equal POST method")
+	assert.Equal(t, "text/plain", r2.getUnderlyingRequest().Header.Get("Content-Type"), "Should have kept the body")
+}
+
+func TestRequestTemplate(t *testing.T) {
+	base := NewRequestBuilder().WithBaseUrl(POSTMAN_ECHO_ROOT).WithBearerAuth(TEST_TOKEN).WithTimeout(5 * time.Second)
+	template := base.Template()
+
+	// Changes to the original builder after the snapshot are not seen by the template.
+	base.WithHeader("X-Late", "late")
+
+	r1, err := template.NewRequestBuilder().WithMethod("POST").WithUrl("/post").WithJsonBody("{}").Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r2, err := template.NewRequestBuilder().WithUrl("/get").Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	for _, r := range []Request{r1, r2} {
+		assert.Equal(t, "Bearer "+TEST_TOKEN, r.getUnderlyingRequest().Header.Get("Authorization"), "Should have inherited auth")
+		assert.Empty(t, r.getUnderlyingRequest().Header.Get("X-Late"), "Should not have seen later changes")
+		assert.Equal(t, 5*time.Second, r.getUnderlyingHttpClient().Timeout, "Should have inherited timeout")
+	}
+
+	assert.Equal(t, http.MethodGet, r2.getUnderlyingRequest().Method, "Should not have inherited method")
+	assert.Empty(t, r2.getUnderlyingRequest().Header.Get("Content-Type"), "Should not have inherited body")
+}
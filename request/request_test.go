@@ -1 +1,302 @@
 package request
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestDo(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		resp.WriteHeader(http.StatusOK)
+		fmt.Fprintf(resp, "Hello World")
+	}))
+	defer ts.Close()
+
+	req, err := NewRequestBuilder().WithUrl(ts.URL).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r, err := req.Do()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.NotNil(t, r, "Should not be nil")
+	assert.Equal(t, http.StatusOK, r.Response().StatusCode, "Should equal HTTP Status 200 (OK)")
+	assert.Equal(t, "Hello World", string(r.Body()), "Should equal response body")
+}
+
+func TestRequestDoTransportError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {}))
+	url := ts.URL
+	ts.Close()
+
+	req, err := NewRequestBuilder().WithUrl(url).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r, err := req.Do()
+
+	assert.Nil(t, r, "Should be nil")
+	assert.NotNil(t, err, "Should not be nil")
+
+	e, ok := err.(*TransportError)
+
+	assert.True(t, ok, "Should be a TransportError")
+	assert.Equal(t, "GET", e.Method, "Should equal request method")
+	assert.Equal(t, url, e.Url, "Should equal request URL")
+}
+
+func TestRequestDoBodyReadError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		// REMARKS: Promise more content than is written so the client hits an unexpected EOF.
+		resp.Header().Set("Content-Length", "100")
+		resp.WriteHeader(http.StatusOK)
+		fmt.Fprintf(resp, "Hello World")
+	}))
+	defer ts.Close()
+
+	req, err := NewRequestBuilder().WithUrl(ts.URL).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r, err := req.Do()
+
+	assert.Nil(t, r, "Should be nil")
+	assert.NotNil(t, err, "Should not be nil")
+
+	e, ok := err.(*BodyReadError)
+
+	assert.True(t, ok, "Should be a BodyReadError")
+	assert.Equal(t, http.StatusOK, e.Response.StatusCode, "Should equal HTTP Status 200 (OK)")
+}
+
+func TestRequestDoContextCanceled(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		<-req.Context().Done()
+	}))
+	defer ts.Close()
+
+	req, err := NewRequestBuilder().WithUrl(ts.URL).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	r, err := req.DoContext(ctx)
+
+	assert.Nil(t, r, "Should be nil")
+	assert.NotNil(t, err, "Should not be nil")
+
+	_, ok := err.(*ContextError)
+
+	assert.True(t, ok, "Should be a ContextError")
+	assert.True(t, errors.Is(err, context.Canceled), "Should unwrap to context.Canceled")
+}
+
+func TestRequestDoWithBuilderContextDeadline(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		<-req.Context().Done()
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	req, err := NewRequestBuilder().WithUrl(ts.URL).WithContext(ctx).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r, err := req.Do()
+
+	assert.Nil(t, r, "Should be nil")
+	assert.NotNil(t, err, "Should not be nil")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "Should unwrap to context.DeadlineExceeded")
+}
+
+func TestRequestDoWithBodyReader(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "application/octet-stream", req.Header.Get("Content-Type"), "Should equal Content-Type header")
+
+		b, _ := ioutil.ReadAll(req.Body)
+
+		resp.WriteHeader(http.StatusOK)
+		fmt.Fprintf(resp, "%d", len(b))
+	}))
+	defer ts.Close()
+
+	data := strings.Repeat("a", 1<<20)
+
+	req, err := NewRequestBuilder().WithMethod("PUT").WithUrl(ts.URL).WithBodyReader(ioutil.NopCloser(strings.NewReader(data)), "application/octet-stream").Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r, err := req.Do()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, fmt.Sprintf("%d", len(data)), r.Text(), "Should have streamed the whole body")
+}
+
+func TestRequestDoStream(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		resp.WriteHeader(http.StatusOK)
+		fmt.Fprintf(resp, "Hello World")
+	}))
+	defer ts.Close()
+
+	req, err := NewRequestBuilder().WithUrl(ts.URL).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r, err := req.DoStream()
+
+	assert.Nil(t, err, "Should be nil")
+
+	defer r.Close()
+
+	assert.Nil(t, r.Body(), "Should not have buffered the body")
+
+	b, err := ioutil.ReadAll(r.BodyReader())
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, "Hello World", string(b), "Should equal response body")
+}
+
+func TestResponseWriteToFile(t *testing.T) {
+	data := strings.Repeat("a", 1<<20)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		resp.Header().Set("Content-Length", fmt.Sprintf("%d", len(data)))
+		resp.WriteHeader(http.StatusOK)
+		fmt.Fprint(resp, data)
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "gorequest")
+
+	assert.Nil(t, err, "Should be nil")
+
+	defer os.RemoveAll(dir)
+
+	req, err := NewRequestBuilder().WithUrl(ts.URL).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r, err := req.DoStream()
+
+	assert.Nil(t, err, "Should be nil")
+
+	var lastWritten, lastTotal int64
+
+	path := filepath.Join(dir, "download")
+	written, err := r.WriteToFile(path, func(written, total int64) {
+		lastWritten, lastTotal = written, total
+	})
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, int64(len(data)), written, "Should equal bytes written")
+	assert.Equal(t, int64(len(data)), lastWritten, "Should have reported final progress")
+	assert.Equal(t, int64(len(data)), lastTotal, "Should have reported Content-Length as total")
+
+	b, err := ioutil.ReadFile(path)
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, data, string(b), "Should equal file content")
+}
+
+func TestRequestDoConcurrently(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		b, _ := ioutil.ReadAll(req.Body)
+
+		resp.Header().Set("X-Attempt", req.Header.Get("X-Attempt"))
+		resp.WriteHeader(http.StatusOK)
+		resp.Write(b)
+	}))
+	defer ts.Close()
+
+	var attempts int32
+
+	counter := func(next RoundTripperFunc) RoundTripperFunc {
+		return func(request *http.Request) (*http.Response, error) {
+			request.Header.Set("X-Attempt", fmt.Sprintf("%d", atomic.AddInt32(&attempts, 1)))
+
+			return next(request)
+		}
+	}
+
+	req, err := NewRequestBuilder().WithMethod("POST").WithUrl(ts.URL).WithTextBody("Hello World").WithMiddleware(counter).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			r, err := req.Do()
+
+			assert.Nil(t, err, "Should be nil")
+			assert.Equal(t, "Hello World", r.Text(), "Should have sent the whole body")
+			assert.NotEmpty(t, r.Header("X-Attempt"), "Should have run the middleware")
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, int32(50), atomic.LoadInt32(&attempts), "Should have sent every request")
+}
+
+func TestRequestTemplateConcurrently(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(resp, "%s %s", req.Method, req.Header.Get("X-Id"))
+	}))
+	defer ts.Close()
+
+	template := NewRequestBuilder().WithBaseUrl(ts.URL).WithHeader("X-Base", "base").Template()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+
+		go func(id int) {
+			defer wg.Done()
+
+			method := "GET"
+
+			if id%2 == 0 {
+				method = "POST"
+			}
+
+			req, err := template.NewRequestBuilder().WithMethod(method).WithHeader("X-Id", fmt.Sprintf("%d", id)).Build()
+
+			assert.Nil(t, err, "Should be nil")
+
+			r, err := req.Do()
+
+			assert.Nil(t, err, "Should be nil")
+			assert.Equal(t, fmt.Sprintf("%s %d", method, id), r.Text(), "Should not have leaked state between builders")
+		}(i)
+	}
+
+	wg.Wait()
+}
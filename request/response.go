@@ -1,10 +1,21 @@
 package request
 
-import "net/http"
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+)
 
 type response struct {
 	body     []byte
 	response *http.Response
+	stream   bool
 }
 
 func (r *response) Body() []byte {
@@ -14,3 +25,145 @@ func (r *response) Body() []byte {
 func (r *response) Response() *http.Response {
 	return r.response
 }
+
+func (r *response) Text() string {
+	return string(r.body)
+}
+
+func (r *response) StatusCode() int {
+	return r.response.StatusCode
+}
+
+func (r *response) Header(name string) string {
+	return r.response.Header.Get(name)
+}
+
+func (r *response) IsSuccess() bool {
+	return r.response.StatusCode >= 200 && r.response.StatusCode < 300
+}
+
+func (r *response) IsClientError() bool {
+	return r.response.StatusCode >= 400 && r.response.StatusCode < 500
+}
+
+func (r *response) IsServerError() bool {
+	return r.response.StatusCode >= 500 && r.response.StatusCode < 600
+}
+
+func (r *response) Json(v interface{}) error {
+	return json.Unmarshal(r.body, v)
+}
+
+func (r *response) Xml(v interface{}) error {
+	return xml.Unmarshal(r.body, v)
+}
+
+// REMARKS: Picks the decoder from the Content-Type header. Other content types (e.g. the HTML error page of a proxy),
+// and a missing header, are a ContentTypeError.
+func (r *response) Decode(v interface{}) error {
+	contentType := r.Header("Content-Type")
+
+	switch {
+	case isJsonContentType(contentType):
+		return r.Json(v)
+	case isXmlContentType(contentType):
+		return r.Xml(v)
+	default:
+		return &ContentTypeError{ContentType: contentType}
+	}
+}
+
+// REMARKS: For a streamed response this is the live body, which can only be read once; otherwise a reader over the buffered body.
+func (r *response) BodyReader() io.ReadCloser {
+	if r.stream {
+		return r.response.Body
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(r.body))
+}
+
+// REMARKS: A partially written file is removed if copying fails. The response is closed once the body has been copied.
+func (r *response) WriteToFile(path string, progress ProgressFunc) (int64, error) {
+	defer r.Close()
+
+	file, err := os.Create(path)
+
+	if err != nil {
+		return 0, err
+	}
+
+	var dst io.Writer = file
+
+	if progress != nil {
+		total := r.response.ContentLength
+
+		if !r.stream {
+			total = int64(len(r.body))
+		}
+
+		dst = &progressWriter{writer: file, total: total, progress: progress}
+	}
+
+	written, err := io.Copy(dst, r.BodyReader())
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		os.Remove(path)
+	}
+
+	return written, err
+}
+
+// REMARKS: Read from the Cache-Status header set by the cache (see WithCache).
+func (r *response) CacheStatus() CacheStatus {
+	return parseCacheStatus(strings.Join(r.response.Header.Values("Cache-Status"), ", "))
+}
+
+func (r *response) Close() error {
+	return r.response.Body.Close()
+}
+
+// ***********************************************
+// ********** Private methods/functions **********
+// ***********************************************
+
+// REMARKS: Matches application/json and structured syntax suffixes such as application/problem+json.
+func isJsonContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+
+	if err != nil {
+		return false
+	}
+
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// REMARKS: Matches application/xml, text/xml and structured syntax suffixes such as application/atom+xml.
+func isXmlContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+
+	if err != nil {
+		return false
+	}
+
+	return mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml")
+}
+
+type progressWriter struct {
+	writer   io.Writer
+	written  int64
+	total    int64
+	progress ProgressFunc
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+
+	w.written += int64(n)
+	w.progress(w.written, w.total)
+
+	return n, err
+}
@@ -0,0 +1,192 @@
+package request
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testXmlStruct struct {
+	IntField    int    `xml:"intField"`
+	StringField string `xml:"stringField"`
+}
+
+type testErrorStruct struct {
+	Message string `json:"message"`
+}
+
+func TestResponseHelpers(t *testing.T) {
+	r := &response{
+		body: []byte(`{"intField":10,"stringField":"Hello World","boolField":true}`),
+		response: &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+		},
+	}
+
+	assert.Equal(t, http.StatusOK, r.StatusCode(), "Should equal HTTP Status 200 (OK)")
+	assert.Equal(t, "application/json", r.Header("content-type"), "Should equal Content-Type header")
+	assert.Equal(t, string(r.body), r.Text(), "Should equal response body")
+	assert.True(t, r.IsSuccess(), "Should be a success")
+	assert.False(t, r.IsClientError(), "Should not be a client error")
+	assert.False(t, r.IsServerError(), "Should not be a server error")
+
+	var s testJsonStruct
+
+	assert.Nil(t, r.Json(&s), "Should be nil")
+	assert.Equal(t, 10, s.IntField, "Should equal IntField")
+	assert.Equal(t, "Hello World", s.StringField, "Should equal StringField")
+	assert.True(t, s.BoolField, "Should be true")
+}
+
+func TestResponseStatusClasses(t *testing.T) {
+	clientError := &response{response: &http.Response{StatusCode: http.StatusNotFound}}
+	serverError := &response{response: &http.Response{StatusCode: http.StatusBadGateway}}
+
+	assert.True(t, clientError.IsClientError(), "Should be a client error")
+	assert.False(t, clientError.IsSuccess(), "Should not be a success")
+	assert.True(t, serverError.IsServerError(), "Should be a server error")
+	assert.False(t, serverError.IsClientError(), "Should not be a client error")
+}
+
+func TestResponseDecodeXml(t *testing.T) {
+	r := &response{
+		body: []byte(`<testXmlStruct><intField>10</intField><stringField>Hello World</stringField></testXmlStruct>`),
+		response: &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/atom+xml; charset=utf-8"}},
+		},
+	}
+
+	var s testXmlStruct
+
+	assert.Nil(t, r.Decode(&s), "Should be nil")
+	assert.Equal(t, 10, s.IntField, "Should equal IntField")
+	assert.Equal(t, "Hello World", s.StringField, "Should equal StringField")
+}
+
+func TestRequestDoWithResult(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		resp.Header().Set("Content-Type", "application/json")
+		resp.WriteHeader(http.StatusOK)
+		fmt.Fprintf(resp, `{"intField":10,"stringField":"Hello World","boolField":true}`)
+	}))
+	defer ts.Close()
+
+	var result testJsonStruct
+	var errorResult testErrorStruct
+
+	req, err := NewRequestBuilder().WithUrl(ts.URL).WithResult(&result).WithErrorResult(&errorResult).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	_, err = req.Do()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, 10, result.IntField, "Should equal IntField")
+	assert.Equal(t, "Hello World", result.StringField, "Should equal StringField")
+	assert.Empty(t, errorResult.Message, "Should not have decoded the error result")
+}
+
+func TestRequestDoWithErrorResult(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		resp.Header().Set("Content-Type", "application/json")
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(resp, `{"message":"Bad Request"}`)
+	}))
+	defer ts.Close()
+
+	var result testJsonStruct
+	var errorResult testErrorStruct
+
+	req, err := NewRequestBuilder().WithUrl(ts.URL).WithResult(&result).WithErrorResult(&errorResult).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r, err := req.Do()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.True(t, r.IsClientError(), "Should be a client error")
+	assert.Equal(t, "Bad Request", errorResult.Message, "Should equal error message")
+	assert.Equal(t, 0, result.IntField, "Should not have decoded the result")
+}
+
+func TestRequestDoWithResultDecodeError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		resp.Header().Set("Content-Type", "application/json")
+		resp.WriteHeader(http.StatusOK)
+		fmt.Fprintf(resp, `not json`)
+	}))
+	defer ts.Close()
+
+	var result testJsonStruct
+
+	req, err := NewRequestBuilder().WithUrl(ts.URL).WithResult(&result).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r, err := req.Do()
+
+	assert.Nil(t, r, "Should be nil")
+
+	e, ok := err.(*DecodeError)
+
+	assert.True(t, ok, "Should be a DecodeError")
+	assert.Equal(t, "not json", e.Response.Text(), "Should carry the response")
+}
+
+func TestResponseDecodeContentTypes(t *testing.T) {
+	tests := map[string]bool{
+		"application/json":                true,
+		"application/problem+json":        true,
+		"application/json; charset=utf-8": true,
+		"text/html":                       false,
+		"text/plain":                      false,
+		"":                                false,
+	}
+
+	for contentType, decoded := range tests {
+		r := &response{
+			body:     []byte(`{"intField":10}`),
+			response: &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Content-Type": []string{contentType}}},
+		}
+
+		var s testJsonStruct
+
+		err := r.Decode(&s)
+
+		if decoded {
+			assert.Nil(t, err, "Should have decoded "+contentType)
+			assert.Equal(t, 10, s.IntField, "Should equal IntField")
+		} else {
+			_, ok := err.(*ContentTypeError)
+
+			assert.True(t, ok, "Should not have decoded "+contentType)
+		}
+	}
+}
+
+func TestRequestDoWithErrorResultHtml(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		resp.Header().Set("Content-Type", "text/html")
+		resp.WriteHeader(http.StatusBadGateway)
+		fmt.Fprintf(resp, `<html><body>502 Bad Gateway</body></html>`)
+	}))
+	defer ts.Close()
+
+	var errorResult testErrorStruct
+
+	req, err := NewRequestBuilder().WithUrl(ts.URL).WithErrorResult(&errorResult).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r, err := req.Do()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.True(t, r.IsServerError(), "Should be a server error")
+	assert.Equal(t, "<html><body>502 Bad Gateway</body></html>", r.Text(), "Should have returned the response")
+	assert.Empty(t, errorResult.Message, "Should not have decoded the error page")
+}
@@ -0,0 +1,159 @@
+package request
+
+import (
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type retryBackoff struct {
+	maxAttempts   int
+	baseDelay     time.Duration
+	maxDelay      time.Duration
+	jitter        bool
+	idempotent    bool
+	retryOnStatus func(status int) bool
+	retryOnError  func(err error) bool
+}
+
+func (p *retryBackoff) WithBaseDelay(delay time.Duration) BackoffRetryPolicy {
+	p.baseDelay = delay
+
+	return p
+}
+
+func (p *retryBackoff) WithMaxDelay(delay time.Duration) BackoffRetryPolicy {
+	p.maxDelay = delay
+
+	return p
+}
+
+func (p *retryBackoff) WithJitter(jitter bool) BackoffRetryPolicy {
+	p.jitter = jitter
+
+	return p
+}
+
+func (p *retryBackoff) WithRetryOnStatus(predicate func(status int) bool) BackoffRetryPolicy {
+	p.retryOnStatus = predicate
+
+	return p
+}
+
+func (p *retryBackoff) WithRetryOnError(predicate func(err error) bool) BackoffRetryPolicy {
+	p.retryOnError = predicate
+
+	return p
+}
+
+// REMARKS: By default only idempotent methods are retried, since a POST that timed out may still have been applied by the server.
+func (p *retryBackoff) WithNonIdempotentMethods() BackoffRetryPolicy {
+	p.idempotent = false
+
+	return p
+}
+
+func (p *retryBackoff) MaxAttempts() int {
+	return p.maxAttempts
+}
+
+func (p *retryBackoff) ShouldRetry(request *http.Request, response *http.Response, err error) bool {
+	if p.idempotent && !isIdempotent(request.Method) {
+		return false
+	}
+
+	if err != nil {
+		return p.retryOnError(err)
+	}
+
+	return p.retryOnStatus(response.StatusCode)
+}
+
+// REMARKS: A Retry-After header sent by the server takes precedence over the computed backoff; both are capped by the max delay.
+func (p *retryBackoff) Delay(attempt int, response *http.Response) time.Duration {
+	if response != nil {
+		if d, ok := parseRetryAfter(response.Header.Get("Retry-After")); ok {
+			return p.cap(d)
+		}
+	}
+
+	d := p.baseDelay
+
+	// REMARKS: A max delay of 0 doesn't cap the backoff; it then stops growing before it overflows.
+	for i := 1; i < attempt && d < math.MaxInt64/2 && (p.maxDelay <= 0 || d < p.maxDelay); i++ {
+		d *= 2
+	}
+
+	d = p.cap(d)
+
+	// REMARKS: "Equal jitter"; keeps at least half of the backoff while spreading out clients that failed together.
+	if p.jitter && d > 1 {
+		half := d / 2
+		d = half + time.Duration(rand.Int63n(int64(d-half)))
+	}
+
+	return d
+}
+
+// ***********************************************
+// ********** Private methods/functions **********
+// ***********************************************
+
+func (p *retryBackoff) cap(d time.Duration) time.Duration {
+	if p.maxDelay > 0 && d > p.maxDelay {
+		return p.maxDelay
+	}
+
+	return d
+}
+
+// REMARKS: Ref: https://www.rfc-editor.org/rfc/rfc9110#section-9.2.2
+func isIdempotent(method string) bool {
+	switch strings.ToUpper(method) {
+	case "GET", "HEAD", "OPTIONS", "TRACE", "PUT", "DELETE":
+		return true
+	default:
+		return false
+	}
+}
+
+// REMARKS: Retry-After is either a number of seconds or an HTTP date.
+// Ref: https://www.rfc-editor.org/rfc/rfc9110#section-10.2.3
+func parseRetryAfter(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		d := time.Until(t)
+
+		if d < 0 {
+			d = 0
+		}
+
+		return d, true
+	}
+
+	return 0, false
+}
+
+func defaultRetryOnStatus(status int) bool {
+	return status == http.StatusTooManyRequests || (status >= 500 && status != http.StatusNotImplemented)
+}
+
+func defaultRetryOnError(err error) bool {
+	return true
+}
@@ -0,0 +1,25 @@
+package request
+
+import (
+	"net/http"
+	"time"
+)
+
+type retryNone struct {
+}
+
+func newRetryNone() RetryPolicy {
+	return &retryNone{}
+}
+
+func (p *retryNone) MaxAttempts() int {
+	return 1
+}
+
+func (p *retryNone) ShouldRetry(request *http.Request, response *http.Response, err error) bool {
+	return false
+}
+
+func (p *retryNone) Delay(attempt int, response *http.Response) time.Duration {
+	return 0
+}
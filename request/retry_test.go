@@ -0,0 +1,204 @@
+package request
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryNone(t *testing.T) {
+	policy := newRetryNone()
+
+	assert.Equal(t, 1, policy.MaxAttempts(), "Should equal a single attempt")
+	assert.False(t, policy.ShouldRetry(nil, nil, nil), "Should not retry")
+}
+
+func TestBackoffRetryPolicyDelay(t *testing.T) {
+	policy := NewBackoffRetryPolicy(5).WithBaseDelay(100 * time.Millisecond).WithMaxDelay(300 * time.Millisecond).WithJitter(false)
+
+	assert.Equal(t, 100*time.Millisecond, policy.Delay(1, nil), "Should equal base delay")
+	assert.Equal(t, 200*time.Millisecond, policy.Delay(2, nil), "Should double the delay")
+	assert.Equal(t, 300*time.Millisecond, policy.Delay(3, nil), "Should be capped by max delay")
+	assert.Equal(t, 300*time.Millisecond, policy.Delay(10, nil), "Should be capped by max delay")
+}
+
+func TestBackoffRetryPolicyDelayWithoutMaxDelay(t *testing.T) {
+	policy := NewBackoffRetryPolicy(5).WithBaseDelay(100 * time.Millisecond).WithMaxDelay(0).WithJitter(false)
+
+	assert.Equal(t, 100*time.Millisecond, policy.Delay(1, nil), "Should equal base delay")
+	assert.Equal(t, 800*time.Millisecond, policy.Delay(4, nil), "Should double the delay without a cap")
+	assert.True(t, policy.Delay(100, nil) > 0, "Should not overflow")
+}
+
+func TestBackoffRetryPolicyDelayWithJitter(t *testing.T) {
+	policy := NewBackoffRetryPolicy(5).WithBaseDelay(100 * time.Millisecond)
+
+	for i := 0; i < 100; i++ {
+		d := policy.Delay(2, nil)
+
+		assert.True(t, d >= 100*time.Millisecond && d < 200*time.Millisecond, "Should be within jitter range")
+	}
+}
+
+func TestBackoffRetryPolicyDelayWithRetryAfter(t *testing.T) {
+	policy := NewBackoffRetryPolicy(5).WithMaxDelay(time.Minute)
+
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Set("Retry-After", "3")
+
+	assert.Equal(t, 3*time.Second, policy.Delay(1, resp), "Should honor Retry-After seconds")
+
+	resp.Header.Set("Retry-After", "3600")
+
+	assert.Equal(t, time.Minute, policy.Delay(1, resp), "Should be capped by max delay")
+
+	resp.Header.Set("Retry-After", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+
+	assert.Equal(t, time.Duration(0), policy.Delay(1, resp), "Should not wait for a date in the past")
+}
+
+func TestBackoffRetryPolicyShouldRetry(t *testing.T) {
+	policy := NewBackoffRetryPolicy(3)
+
+	get, _ := http.NewRequest("GET", POSTMAN_ECHO_ROOT, nil)
+	post, _ := http.NewRequest("POST", POSTMAN_ECHO_ROOT, nil)
+
+	assert.True(t, policy.ShouldRetry(get, &http.Response{StatusCode: http.StatusServiceUnavailable}, nil), "Should retry 503")
+	assert.True(t, policy.ShouldRetry(get, &http.Response{StatusCode: http.StatusTooManyRequests}, nil), "Should retry 429")
+	assert.False(t, policy.ShouldRetry(get, &http.Response{StatusCode: http.StatusNotImplemented}, nil), "Should not retry 501")
+	assert.False(t, policy.ShouldRetry(get, &http.Response{StatusCode: http.StatusNotFound}, nil), "Should not retry 404")
+	assert.True(t, policy.ShouldRetry(get, nil, &TransportError{}), "Should retry transport errors")
+	assert.False(t, policy.ShouldRetry(post, &http.Response{StatusCode: http.StatusServiceUnavailable}, nil), "Should not retry POST")
+
+	policy.WithNonIdempotentMethods()
+
+	assert.True(t, policy.ShouldRetry(post, &http.Response{StatusCode: http.StatusServiceUnavailable}, nil), "Should retry POST")
+}
+
+func TestRequestDoWithRetry(t *testing.T) {
+	var calls int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			resp.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		resp.WriteHeader(http.StatusOK)
+		fmt.Fprintf(resp, "Hello World")
+	}))
+	defer ts.Close()
+
+	policy := NewBackoffRetryPolicy(3).WithBaseDelay(time.Millisecond)
+
+	req, err := NewRequestBuilder().WithUrl(ts.URL).WithRetry(policy).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r, err := req.Do()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, http.StatusOK, r.Response().StatusCode, "Should equal HTTP Status 200 (OK)")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls), "Should have made three attempts")
+}
+
+func TestRequestDoWithRetryExhausted(t *testing.T) {
+	var calls int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		resp.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	policy := NewBackoffRetryPolicy(2).WithBaseDelay(time.Millisecond)
+
+	req, err := NewRequestBuilder().WithUrl(ts.URL).WithRetry(policy).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r, err := req.Do()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, http.StatusBadGateway, r.Response().StatusCode, "Should return the last response")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls), "Should have made two attempts")
+}
+
+func TestRequestDoWithRetryReplaysBody(t *testing.T) {
+	var calls int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		b, _ := ioutil.ReadAll(req.Body)
+
+		assert.Equal(t, "Hello World", string(b), "Should equal request body on every attempt")
+
+		if atomic.AddInt32(&calls, 1) < 2 {
+			resp.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		resp.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	policy := NewBackoffRetryPolicy(2).WithBaseDelay(time.Millisecond).WithNonIdempotentMethods()
+
+	req, err := NewRequestBuilder().WithMethod("POST").WithUrl(ts.URL).WithTextBody("Hello World").WithRetry(policy).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r, err := req.Do()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, http.StatusOK, r.Response().StatusCode, "Should equal HTTP Status 200 (OK)")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls), "Should have made two attempts")
+}
+
+func TestRequestDoWithRetryDoesNotRetryPostByDefault(t *testing.T) {
+	var calls int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		resp.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	policy := NewBackoffRetryPolicy(3).WithBaseDelay(time.Millisecond)
+
+	req, err := NewRequestBuilder().WithMethod("POST").WithUrl(ts.URL).WithTextBody("Hello World").WithRetry(policy).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	_, err = req.Do()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "Should have made a single attempt")
+}
+
+func TestRequestDoWithRetryDoesNotRetryBodyReader(t *testing.T) {
+	var calls int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		resp.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	policy := NewBackoffRetryPolicy(3).WithBaseDelay(time.Millisecond)
+
+	req, err := NewRequestBuilder().WithMethod("PUT").WithUrl(ts.URL).WithBodyReader(ioutil.NopCloser(strings.NewReader("Hello World")), "text/plain").WithRetry(policy).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	_, err = req.Do()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "Should have made a single attempt")
+}
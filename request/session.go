@@ -0,0 +1,97 @@
+package request
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// REMARKS: A Client with a cookie jar: the cookies set by responses to the requests built from it (redirects included)
+// are sent with the requests that follow. Safe for concurrent use, like Client.
+type session struct {
+	*client
+	jar *sessionJar
+}
+
+func (s *session) WithTransport(transport http.RoundTripper) Session {
+	s.client.WithTransport(transport)
+
+	return s
+}
+
+func (s *session) WithTimeout(timeout time.Duration) Session {
+	s.client.WithTimeout(timeout)
+
+	return s
+}
+
+func (s *session) WithBaseUrl(url string) Session {
+	s.client.WithBaseUrl(url)
+
+	return s
+}
+
+func (s *session) WithMiddleware(middleware ...Middleware) Session {
+	s.client.WithMiddleware(middleware...)
+
+	return s
+}
+
+func (s *session) WithCache(store CacheStore) Session {
+	s.client.WithCache(store)
+
+	return s
+}
+
+func (s *session) WithLogger(logger Logger) Session {
+	s.client.WithLogger(logger)
+
+	return s
+}
+
+func (s *session) WithLogPolicy(policy LogPolicy) Session {
+	s.client.WithLogPolicy(policy)
+
+	return s
+}
+
+func (s *session) WithHarRecorder(recorder HarRecorder) Session {
+	s.client.WithHarRecorder(recorder)
+
+	return s
+}
+
+func (s *session) Jar() http.CookieJar {
+	return s.jar
+}
+
+func (s *session) Cookies(rawUrl string) ([]*http.Cookie, error) {
+	u, err := url.Parse(rawUrl)
+
+	if err != nil {
+		return nil, &UrlError{Url: rawUrl, Err: err}
+	}
+
+	return s.jar.Cookies(u), nil
+}
+
+func (s *session) SetCookies(rawUrl string, cookies ...*http.Cookie) error {
+	u, err := url.Parse(rawUrl)
+
+	if err != nil {
+		return &UrlError{Url: rawUrl, Err: err}
+	}
+
+	s.jar.SetCookies(u, cookies)
+
+	return nil
+}
+
+// REMARKS: Session cookies (without an expiry) are saved too, so that a login can be reused by a later run.
+func (s *session) SaveCookies(path string, format CookieFormat) error {
+	return s.jar.save(path, format)
+}
+
+func (s *session) LoadCookies(path string, format CookieFormat) error {
+	return s.jar.load(path, format)
+}
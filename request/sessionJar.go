@@ -0,0 +1,313 @@
+package request
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// REMARKS: A cookiejar.Jar (with the public suffix list, so that a site cannot set cookies for e.g. "co.uk") that
+// also keeps a record of the cookies it was given, since cookiejar.Jar does not expose them and saving them requires
+// it. When saving, each recorded cookie is checked against the jar, so the cookies it rejected or expired are left out.
+type sessionJar struct {
+	mutex   sync.Mutex
+	jar     *cookiejar.Jar
+	entries map[string]*cookieEntry
+	now     func() time.Time
+}
+
+// REMARKS: The JSON format of the saved cookies; a nil Expires is a session cookie.
+type cookieEntry struct {
+	Name     string     `json:"name"`
+	Value    string     `json:"value"`
+	Domain   string     `json:"domain"`
+	Path     string     `json:"path"`
+	Expires  *time.Time `json:"expires,omitempty"`
+	Secure   bool       `json:"secure"`
+	HttpOnly bool       `json:"httpOnly"`
+	HostOnly bool       `json:"hostOnly"`
+}
+
+const netscapeHeader = "# Netscape HTTP Cookie File"
+const netscapeHttpOnlyPrefix = "#HttpOnly_"
+
+func newSessionJar() *sessionJar {
+	// REMARKS: cookiejar.New only fails when given invalid options.
+	jar, _ := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+
+	return &sessionJar{
+		jar:     jar,
+		entries: make(map[string]*cookieEntry),
+		now:     time.Now,
+	}
+}
+
+func (j *sessionJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	j.jar.SetCookies(u, cookies)
+
+	now := j.now()
+
+	for _, cookie := range cookies {
+		entry := &cookieEntry{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Domain:   strings.ToLower(strings.TrimPrefix(cookie.Domain, ".")),
+			Path:     cookie.Path,
+			Secure:   cookie.Secure,
+			HttpOnly: cookie.HttpOnly,
+		}
+
+		if entry.Domain == "" {
+			entry.Domain = strings.ToLower(u.Hostname())
+			entry.HostOnly = true
+		}
+
+		if !strings.HasPrefix(entry.Path, "/") {
+			entry.Path = defaultCookiePath(u.Path)
+		}
+
+		key := entry.Domain + ";" + entry.Path + ";" + entry.Name
+		expired := false
+
+		switch {
+		case cookie.MaxAge < 0:
+			expired = true
+		case cookie.MaxAge > 0:
+			expires := now.Add(time.Duration(cookie.MaxAge) * time.Second)
+			entry.Expires = &expires
+		case !cookie.Expires.IsZero():
+			expires := cookie.Expires
+			entry.Expires = &expires
+			expired = !expires.After(now)
+		}
+
+		if expired {
+			delete(j.entries, key)
+		} else {
+			j.entries[key] = entry
+		}
+	}
+}
+
+func (j *sessionJar) Cookies(u *url.URL) []*http.Cookie {
+	return j.jar.Cookies(u)
+}
+
+// ***********************************************
+// ********** Private methods/functions **********
+// ***********************************************
+
+func (j *sessionJar) save(path string, format CookieFormat) error {
+	entries := j.list()
+
+	var data []byte
+
+	if format == CookieFormatJson {
+		b, err := json.MarshalIndent(entries, "", "  ")
+
+		if err != nil {
+			return err
+		}
+
+		data = b
+	} else {
+		data = formatNetscape(entries)
+	}
+
+	// REMARKS: Cookies often hold credentials; the file is only readable by its owner.
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// REMARKS: Adds the cookies to the jar, replacing those with the same domain, path and name.
+func (j *sessionJar) load(path string, format CookieFormat) error {
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return err
+	}
+
+	var entries []*cookieEntry
+
+	if format == CookieFormatJson {
+		err = json.Unmarshal(data, &entries)
+	} else {
+		entries, err = parseNetscape(data)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		cookie := &http.Cookie{
+			Name:     entry.Name,
+			Value:    entry.Value,
+			Path:     entry.Path,
+			Secure:   entry.Secure,
+			HttpOnly: entry.HttpOnly,
+		}
+
+		if !entry.HostOnly {
+			cookie.Domain = entry.Domain
+		}
+
+		if entry.Expires != nil {
+			cookie.Expires = *entry.Expires
+		}
+
+		j.SetCookies(entry.url(), []*http.Cookie{cookie})
+	}
+
+	return nil
+}
+
+// REMARKS: Returns the recorded cookies that are still in the jar, sorted by domain, path and name.
+func (j *sessionJar) list() []*cookieEntry {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	keys := make([]string, 0, len(j.entries))
+
+	for key := range j.entries {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	entries := make([]*cookieEntry, 0, len(keys))
+
+	for _, key := range keys {
+		entry := j.entries[key]
+
+		for _, cookie := range j.jar.Cookies(entry.url()) {
+			if cookie.Name == entry.Name && cookie.Value == entry.Value {
+				entries = append(entries, entry)
+				break
+			}
+		}
+	}
+
+	return entries
+}
+
+func (e *cookieEntry) url() *url.URL {
+	scheme := "http"
+
+	if e.Secure {
+		scheme = "https"
+	}
+
+	return &url.URL{Scheme: scheme, Host: e.Domain, Path: e.Path}
+}
+
+// REMARKS: Ref: https://www.rfc-editor.org/rfc/rfc6265#section-5.1.4
+func defaultCookiePath(path string) string {
+	i := strings.LastIndex(path, "/")
+
+	if i <= 0 {
+		return "/"
+	}
+
+	return path[:i]
+}
+
+// REMARKS: The format of curl and wget (--load-cookies), one cookie per line with tab-separated fields: domain,
+// include subdomains, path, secure, expiry (Unix time, 0 for session cookies), name and value.
+func formatNetscape(entries []*cookieEntry) []byte {
+	var b bytes.Buffer
+
+	b.WriteString(netscapeHeader + "\n\n")
+
+	for _, entry := range entries {
+		domain := entry.Domain
+
+		if !entry.HostOnly {
+			domain = "." + domain
+		}
+
+		if entry.HttpOnly {
+			domain = netscapeHttpOnlyPrefix + domain
+		}
+
+		var expires int64
+
+		if entry.Expires != nil {
+			expires = entry.Expires.Unix()
+		}
+
+		fmt.Fprintf(&b, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", domain, netscapeBool(!entry.HostOnly), entry.Path, netscapeBool(entry.Secure), expires, entry.Name, entry.Value)
+	}
+
+	return b.Bytes()
+}
+
+func parseNetscape(data []byte) ([]*cookieEntry, error) {
+	var entries []*cookieEntry
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		httpOnly := strings.HasPrefix(text, netscapeHttpOnlyPrefix)
+
+		if httpOnly {
+			text = strings.TrimPrefix(text, netscapeHttpOnlyPrefix)
+		} else if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, "\t")
+
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("Invalid cookie on line %d: expected 7 fields, got %d.", line, len(fields))
+		}
+
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid cookie expiry %q on line %d.", fields[4], line)
+		}
+
+		entry := &cookieEntry{
+			Domain:   strings.ToLower(strings.TrimPrefix(fields[0], ".")),
+			HostOnly: !strings.EqualFold(fields[1], "TRUE"),
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			Name:     fields[5],
+			Value:    fields[6],
+			HttpOnly: httpOnly,
+		}
+
+		if expires > 0 {
+			t := time.Unix(expires, 0).UTC()
+			entry.Expires = &t
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, scanner.Err()
+}
+
+func netscapeBool(b bool) string {
+	if b {
+		return "TRUE"
+	}
+
+	return "FALSE"
+}
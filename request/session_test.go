@@ -0,0 +1,209 @@
+package request
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newLoginServer() *httptest.Server {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/login", func(resp http.ResponseWriter, req *http.Request) {
+		http.SetCookie(resp, &http.Cookie{Name: "session", Value: "abc123", Path: "/", HttpOnly: true})
+		http.Redirect(resp, req, "/home", http.StatusFound)
+	})
+
+	mux.HandleFunc("/home", func(resp http.ResponseWriter, req *http.Request) {
+		if cookie, err := req.Cookie("session"); err != nil || cookie.Value != "abc123" {
+			resp.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		resp.WriteHeader(http.StatusOK)
+		resp.Write([]byte(req.Header.Get("Cookie")))
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestSessionKeepsCookies(t *testing.T) {
+	ts := newLoginServer()
+	defer ts.Close()
+
+	session := NewSession()
+
+	req, err := session.NewRequestBuilder().WithMethod("POST").WithUrl(ts.URL + "/login").Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r, err := req.Do()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, http.StatusOK, r.StatusCode(), "Should have sent the cookie when following the redirect")
+
+	req, err = session.NewRequestBuilder().WithUrl(ts.URL + "/home").WithCookie(&http.Cookie{Name: "theme", Value: "dark"}).WithCookie(nil).Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r, err = req.Do()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, http.StatusOK, r.StatusCode(), "Should have sent the cookie with the next request")
+	assert.Equal(t, "theme=dark; session=abc123", r.Text(), "Should have sent both cookies, skipping the nil one")
+
+	cookies, err := session.Cookies(ts.URL)
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, 1, len(cookies), "Should not have stored the cookie set with WithCookie")
+
+	// Requests that are not built from the session don't share its cookies.
+	req, err = NewRequestBuilder().WithUrl(ts.URL + "/home").Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	r, err = req.Do()
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, http.StatusUnauthorized, r.StatusCode(), "Should equal HTTP Status 401 (Unauthorized)")
+}
+
+func TestSessionConfiguration(t *testing.T) {
+	ts := newLoginServer()
+	defer ts.Close()
+
+	// The With* methods keep the Session, and therefore its cookies.
+	session := NewSession().WithBaseUrl(ts.URL).WithTimeout(5 * time.Second)
+
+	req, err := session.NewRequestBuilder().WithMethod("POST").WithUrl("/login").Build()
+
+	assert.Nil(t, err, "Should be nil")
+
+	_, err = req.Do()
+
+	assert.Nil(t, err, "Should be nil")
+
+	cookies, err := session.Cookies(ts.URL)
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, 1, len(cookies), "Should have stored the cookie of the login")
+}
+
+func TestSessionPublicSuffix(t *testing.T) {
+	session := NewSession()
+
+	err := session.SetCookies("https://shop.example.co.uk/", &http.Cookie{Name: "a", Value: "1", Domain: "co.uk"}, &http.Cookie{Name: "b", Value: "2", Domain: "example.co.uk"})
+
+	assert.Nil(t, err, "Should be nil")
+
+	cookies, err := session.Cookies("https://other.co.uk/")
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Empty(t, cookies, "Should have rejected the cookie for a public suffix")
+
+	cookies, err = session.Cookies("https://www.example.co.uk/")
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, []*http.Cookie{{Name: "b", Value: "2"}}, cookies, "Should have kept the cookie for the registered domain")
+
+	dir, err := ioutil.TempDir("", "gorequest")
+
+	assert.Nil(t, err, "Should be nil")
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "cookies.json")
+
+	assert.Nil(t, session.SaveCookies(path, CookieFormatJson), "Should be nil")
+
+	data, err := ioutil.ReadFile(path)
+
+	assert.Nil(t, err, "Should be nil")
+	assert.False(t, strings.Contains(string(data), `"name": "a"`), "Should not have saved the rejected cookie")
+}
+
+func TestSessionSaveAndLoadCookies(t *testing.T) {
+	expires := time.Now().Add(time.Hour).Truncate(time.Second).UTC()
+
+	dir, err := ioutil.TempDir("", "gorequest")
+
+	assert.Nil(t, err, "Should be nil")
+
+	defer os.RemoveAll(dir)
+
+	for i, format := range []CookieFormat{CookieFormatNetscape, CookieFormatJson} {
+		session := NewSession()
+
+		err = session.SetCookies("https://www.example.com/app/login",
+			&http.Cookie{Name: "host", Value: "1"},
+			&http.Cookie{Name: "domain", Value: "2", Domain: ".example.com", Path: "/", Expires: expires},
+			&http.Cookie{Name: "secure", Value: "3", Path: "/", Secure: true, HttpOnly: true, MaxAge: 3600},
+			&http.Cookie{Name: "deleted", Value: "4", Path: "/"},
+		)
+
+		assert.Nil(t, err, "Should be nil")
+		assert.Nil(t, session.SetCookies("https://www.example.com/", &http.Cookie{Name: "deleted", Path: "/", MaxAge: -1}), "Should be nil")
+
+		path := filepath.Join(dir, fmt.Sprintf("cookies-%d", i))
+
+		assert.Nil(t, session.SaveCookies(path, format), "Should be nil")
+
+		loaded := NewSession()
+
+		assert.Nil(t, loaded.LoadCookies(path, format), "Should be nil")
+
+		cookies, err := loaded.Cookies("https://www.example.com/app/page")
+
+		assert.Nil(t, err, "Should be nil")
+		assert.ElementsMatch(t, []*http.Cookie{{Name: "host", Value: "1"}, {Name: "domain", Value: "2"}, {Name: "secure", Value: "3"}}, cookies, "Should have loaded the cookies")
+
+		cookies, err = loaded.Cookies("http://api.example.com/")
+
+		assert.Nil(t, err, "Should be nil")
+		assert.Equal(t, []*http.Cookie{{Name: "domain", Value: "2"}}, cookies, "Should have kept the domain and secure attributes")
+	}
+}
+
+func TestSessionNetscapeFormat(t *testing.T) {
+	session := NewSession()
+
+	err := session.SetCookies("https://www.example.com/",
+		&http.Cookie{Name: "domain", Value: "2", Domain: "example.com", Path: "/", Expires: time.Unix(4102444800, 0)},
+		&http.Cookie{Name: "host", Value: "1", Path: "/", Secure: true, HttpOnly: true},
+	)
+
+	assert.Nil(t, err, "Should be nil")
+
+	dir, err := ioutil.TempDir("", "gorequest")
+
+	assert.Nil(t, err, "Should be nil")
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "cookies.txt")
+
+	assert.Nil(t, session.SaveCookies(path, CookieFormatNetscape), "Should be nil")
+
+	data, err := ioutil.ReadFile(path)
+
+	assert.Nil(t, err, "Should be nil")
+
+	expected := "# Netscape HTTP Cookie File\n\n" +
+		".example.com\tTRUE\t/\tFALSE\t4102444800\tdomain\t2\n" +
+		"#HttpOnly_www.example.com\tFALSE\t/\tTRUE\t0\thost\t1\n"
+
+	assert.Equal(t, expected, string(data), "Should equal the cookies.txt of curl")
+
+	entries, err := parseNetscape([]byte("# comment\n\n.example.org\tTRUE\t/\tFALSE\t0\ta\tb\nbroken line\n"))
+
+	assert.Nil(t, entries, "Should be nil")
+	assert.NotNil(t, err, "Should not be able to parse a line without 7 fields")
+}
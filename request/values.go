@@ -0,0 +1,185 @@
+package request
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TODO: Document
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// REMARKS: Encodes the exported fields of a struct (or pointer to struct) as url.Values, using the given tag for names
+// and options, e.g. `form:"name,omitempty"`. A tag of "-" skips the field; a field without a tag uses its own name.
+// REMARKS: Slices and arrays repeat the key, time.Time is encoded as RFC 3339, and embedded structs are flattened.
+func encodeValues(v interface{}, tag string) (url.Values, error) {
+	indirect := reflect.Indirect(reflect.ValueOf(v))
+
+	if indirect.Kind() != reflect.Struct {
+		return nil, errors.New("Can only encode a struct as form or query values.")
+	}
+
+	values := url.Values{}
+
+	if err := encodeStruct(values, indirect, tag); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// ***********************************************
+// ********** Private methods/functions **********
+// ***********************************************
+
+func encodeStruct(values url.Values, value reflect.Value, tag string) error {
+	t := value.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldValue := value.Field(i)
+
+		name, options := parseTag(field.Tag.Get(tag))
+
+		if name == "-" {
+			continue
+		}
+
+		// REMARKS: Untagged embedded structs are flattened into the parent, the same way encoding/json does it.
+		if field.Anonymous && name == "" {
+			if fieldValue.Kind() == reflect.Ptr && fieldValue.IsNil() {
+				continue
+			}
+
+			embedded := reflect.Indirect(fieldValue)
+
+			if embedded.Kind() == reflect.Struct && embedded.Type() != timeType {
+				if err := encodeStruct(values, embedded, tag); err != nil {
+					return err
+				}
+
+				continue
+			}
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		if options.contains("omitempty") && isEmptyValue(fieldValue) {
+			continue
+		}
+
+		if err := encodeValue(values, name, fieldValue); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func encodeValue(values url.Values, name string, value reflect.Value) error {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			values.Add(name, "")
+
+			return nil
+		}
+
+		value = value.Elem()
+	}
+
+	if value.Type() == timeType {
+		values.Add(name, value.Interface().(time.Time).Format(time.RFC3339))
+
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		// REMARKS: []byte is treated as a string rather than a list of numbers.
+		if value.Type().Elem().Kind() == reflect.Uint8 && value.Kind() == reflect.Slice {
+			values.Add(name, string(value.Bytes()))
+
+			return nil
+		}
+
+		for i := 0; i < value.Len(); i++ {
+			if err := encodeValue(values, name, value.Index(i)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case reflect.String:
+		values.Add(name, value.String())
+	case reflect.Bool:
+		values.Add(name, strconv.FormatBool(value.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		values.Add(name, strconv.FormatInt(value.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		values.Add(name, strconv.FormatUint(value.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		values.Add(name, strconv.FormatFloat(value.Float(), 'f', -1, value.Type().Bits()))
+	default:
+		if s, ok := value.Interface().(fmt.Stringer); ok {
+			values.Add(name, s.String())
+
+			return nil
+		}
+
+		return fmt.Errorf("Cannot encode field %q of type %s.", name, value.Type())
+	}
+
+	return nil
+}
+
+type tagOptions []string
+
+func (o tagOptions) contains(option string) bool {
+	for _, v := range o {
+		if v == option {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseTag(tag string) (string, tagOptions) {
+	parts := strings.Split(tag, ",")
+
+	return parts[0], tagOptions(parts[1:])
+}
+
+func isEmptyValue(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return value.Len() == 0
+	case reflect.Bool:
+		return !value.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return value.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return value.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return value.IsNil()
+	}
+
+	if value.Type() == timeType {
+		return value.Interface().(time.Time).IsZero()
+	}
+
+	return false
+}
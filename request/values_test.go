@@ -0,0 +1,55 @@
+package request
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testValuesEmbedded struct {
+	Page int `form:"page"`
+}
+
+type testValuesStruct struct {
+	testValuesEmbedded
+	Name     string    `form:"name"`
+	Tags     []string  `form:"tag"`
+	Since    time.Time `form:"since"`
+	Optional string    `form:"optional,omitempty"`
+	Pointer  *int      `form:"pointer,omitempty"`
+	Skipped  string    `form:"-"`
+	Untagged bool
+	private  string
+}
+
+func TestEncodeValues(t *testing.T) {
+	since := time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	values, err := encodeValues(&testValuesStruct{
+		testValuesEmbedded: testValuesEmbedded{Page: 2},
+		Name:               "Hello World",
+		Tags:               []string{"a", "b"},
+		Since:              since,
+		Skipped:            "skipped",
+		Untagged:           true,
+		private:            "private",
+	}, "form")
+
+	assert.Nil(t, err, "Should be nil")
+	assert.Equal(t, url.Values{
+		"page":     []string{"2"},
+		"name":     []string{"Hello World"},
+		"tag":      []string{"a", "b"},
+		"since":    []string{"2017-06-01T12:00:00Z"},
+		"Untagged": []string{"true"},
+	}, values, "Should equal encoded values")
+}
+
+func TestEncodeValuesError(t *testing.T) {
+	values, err := encodeValues("Hello World", "form")
+
+	assert.Nil(t, values, "Should be nil")
+	assert.NotNil(t, err, "Should not be nil")
+}